@@ -28,13 +28,20 @@ func main() {
 
 	a := app.New()
 
+	if flag.Arg(0) == "migrate" {
+		if err := a.Migrate(flag.Arg(1)); err != nil {
+			log.Fatalf("migrate: %s\n", err)
+		}
+		return
+	}
+
 	go func() {
 		if err := a.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("listen: %s\n", err)
 		}
 	}()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
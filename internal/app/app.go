@@ -6,18 +6,25 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"os"
+	"project/internal/app/controllers/apikeycontroller"
 	"project/internal/app/controllers/bannercontroller"
 	"project/internal/app/controllers/middleware/authmiddleware"
+	"project/internal/app/controllers/tokencontroller"
 	"project/internal/app/infrastructure/cache"
 	"project/internal/app/infrastructure/repository"
+	"project/internal/app/models"
 	"project/internal/app/services/authservice"
 	"project/internal/app/services/bannerservice"
 	"project/internal/logger"
+	"time"
 )
 
 type app struct {
 	log    logger.Logger
 	server *http.Server
+	// ctx lives until Stop and bounds background workers started by Run.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func New() *app {
@@ -26,9 +33,12 @@ func New() *app {
 	srv := &http.Server{
 		Addr: fmt.Sprintf(":%s", port),
 	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &app{
 		log:    l,
 		server: srv,
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
@@ -38,18 +48,44 @@ func (a *app) Run() error {
 		return err
 	}
 
+	if err := repo.CheckSchema(a.ctx); err != nil {
+		a.log.Errorf("app.Run Refusing to start: %s", err)
+		return err
+	}
+
 	c, err := cache.New(a.log)
 	if err != nil {
 		return err
 	}
 
-	bannerService := bannerservice.New(a.log, repo, c)
-	authService := authservice.New(a.log)
+	mc, err := cache.NewMemory(a.log, c)
+	if err != nil {
+		return err
+	}
+	go mc.Listen(a.ctx)
+	go mc.RunStatsLogger(a.ctx)
+
+	bannerService, err := bannerservice.New(a.log, repo, mc)
+	if err != nil {
+		return err
+	}
+	go bannerService.RunPurger(a.ctx)
+	go bannerService.RunJobs(a.ctx)
+
+	authService, err := authservice.New(a.log, repo, c)
+	if err != nil {
+		return err
+	}
+	go authService.RunKeyRefresher(a.ctx)
 
 	authMiddleware := authmiddleware.New(a.log, authService)
 
 	bannerController := bannercontroller.New(a.log, bannerService)
 
+	apiKeyController := apikeycontroller.New(a.log, authService)
+
+	tokenController := tokencontroller.New(a.log, authService)
+
 	router := gin.Default()
 
 	userBannerRouter := router.Group("/user_banner")
@@ -58,13 +94,54 @@ func (a *app) Run() error {
 		userBannerRouter.GET("/", bannerController.GetUserBannerHandler())
 	}
 
+	// Handlers check feature-scoped grants against the banners they touch.
+	read := authMiddleware.RequireScope(models.ScopeBannersRead)
+	write := authMiddleware.RequireScope(models.ScopeBannersWrite)
+	del := authMiddleware.RequireScope(models.ScopeBannersDelete)
+
 	bannerGroup := router.Group("/banner")
-	bannerGroup.Use(authMiddleware.Auth(), authMiddleware.AdminRequired())
+	bannerGroup.Use(authMiddleware.Auth())
 	{
-		bannerGroup.GET("/", bannerController.GetHandler())
-		bannerGroup.POST("/", bannerController.PostHandler())
-		bannerGroup.PATCH("/:id", bannerController.PatchHandler())
-		bannerGroup.DELETE("/:id", bannerController.DeleteHandler())
+		bannerGroup.GET("/", read, bannerController.GetHandler())
+		bannerGroup.POST("/", write, bannerController.PostHandler())
+		bannerGroup.POST("/bulk", write, bannerController.BulkPostHandler())
+		bannerGroup.GET("/export", read, bannerController.ExportHandler())
+		bannerGroup.GET("/:id", read, bannerController.GetBannerHandler())
+		bannerGroup.PATCH("/:id", write, bannerController.PatchHandler())
+		bannerGroup.DELETE("/", del, bannerController.DeleteManyHandler())
+		bannerGroup.DELETE("/:id", del, bannerController.DeleteHandler())
+		bannerGroup.POST("/:id/restore", write, bannerController.RestoreHandler())
+		bannerGroup.GET("/versions/:id", read, bannerController.GetVersionsHandler())
+		bannerGroup.PUT("/versions/:id/activate", write, bannerController.ActivateVersionHandler())
+	}
+
+	featureGroup := router.Group("/features")
+	featureGroup.Use(authMiddleware.Auth(), authMiddleware.AdminRequired())
+	{
+		featureGroup.GET("/:id/schema", bannerController.GetFeatureSchemaHandler())
+		featureGroup.PUT("/:id/schema", bannerController.PutFeatureSchemaHandler())
+		featureGroup.DELETE("/:id/schema", bannerController.DeleteFeatureSchemaHandler())
+	}
+
+	jobsGroup := router.Group("/jobs")
+	jobsGroup.Use(authMiddleware.Auth())
+	{
+		jobsGroup.GET("/:id", del, bannerController.GetJobHandler())
+	}
+
+	apiKeyGroup := router.Group("/api_keys")
+	apiKeyGroup.Use(authMiddleware.Auth(), authMiddleware.AdminRequired())
+	{
+		apiKeyGroup.GET("/", apiKeyController.GetHandler())
+		apiKeyGroup.POST("/", apiKeyController.PostHandler())
+		apiKeyGroup.DELETE("/:id", apiKeyController.DeleteHandler())
+	}
+
+	tokenGroup := router.Group("/tokens")
+	tokenGroup.Use(authMiddleware.Auth(), authMiddleware.AdminRequired())
+	{
+		tokenGroup.POST("/revoke", tokenController.RevokeTokenHandler())
+		tokenGroup.POST("/revoke_subject", tokenController.RevokeSubjectHandler())
 	}
 
 	a.server.Handler = router
@@ -72,6 +149,37 @@ func (a *app) Run() error {
 	return a.server.ListenAndServe()
 }
 
+// Migrate runs the migrate subcommand: up, down or status.
+func (a *app) Migrate(command string) error {
+	repo, err := repository.New(a.log)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		return repo.MigrateUp(a.ctx)
+	case "down":
+		return repo.MigrateDown(a.ctx)
+	case "status":
+		statuses, err := repo.MigrationStatus(a.ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied at " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+}
+
 func (a *app) Stop(ctx context.Context) error {
+	a.cancel()
 	return a.server.Shutdown(ctx)
 }
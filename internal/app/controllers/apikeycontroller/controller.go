@@ -0,0 +1,23 @@
+package apikeycontroller
+
+import (
+	"project/internal/logger"
+)
+
+type apiKeyService interface {
+	apiKeyIssuer
+	apiKeysGetter
+	apiKeyRevoker
+}
+
+type controller struct {
+	log logger.Logger
+	as  apiKeyService
+}
+
+func New(log logger.Logger, as apiKeyService) *controller {
+	return &controller{
+		log: log,
+		as:  as,
+	}
+}
@@ -0,0 +1,41 @@
+package apikeycontroller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type apiKeyRevoker interface {
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+// DeleteHandler revokes the key, it stays listed with revoked_at set.
+func (c *controller) DeleteHandler() gin.HandlerFunc {
+	const op = "apikeycontroller.DeleteHandler"
+	return func(ctx *gin.Context) {
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		err = c.as.RevokeAPIKey(ctx, id)
+		if errors.Is(err, models.APIKeyNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, APIKeyNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to revoke api key: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,27 @@
+package apikeycontroller
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type apiKeysGetter interface {
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+}
+
+func (c *controller) GetHandler() gin.HandlerFunc {
+	const op = "apikeycontroller.GetHandler"
+	return func(ctx *gin.Context) {
+		keys, err := c.as.GetAPIKeys(ctx)
+		if err != nil {
+			c.log.Errorf("%s : Failed to get api keys: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.IndentedJSON(http.StatusOK, &keys)
+	}
+}
@@ -0,0 +1,72 @@
+package apikeycontroller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+	"time"
+)
+
+type apiKeyIssuer interface {
+	IssueAPIKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (models.IssuedAPIKey, error)
+}
+
+type postAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// PostHandler issues a key, the response is the only place the key is shown.
+func (c *controller) PostHandler() gin.HandlerFunc {
+	const op = "apikeycontroller.PostHandler"
+	return func(ctx *gin.Context) {
+		var req postAPIKeyRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			c.log.Errorf("%s : Failed to parse body: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		if err := validatePostAPIKey(req, time.Now()); err != nil {
+			c.log.Errorf("%s : Invalid api key request: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		key, err := c.as.IssueAPIKey(ctx, req.Name, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to issue api key: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, &key)
+	}
+}
+
+// validatePostAPIKey reports every invalid field of the request.
+func validatePostAPIKey(req postAPIKeyRequest, now time.Time) error {
+	var errs []error
+	if req.Name == "" {
+		errs = append(errs, controllers.NewFieldError(controllers.FieldRequired, "name", "name is required"))
+	}
+
+	if len(req.Scopes) == 0 {
+		errs = append(errs, controllers.NewFieldError(controllers.FieldRequired, "scopes", "scopes are required"))
+	}
+	for _, scope := range req.Scopes {
+		if !models.ValidScope(scope) {
+			errs = append(errs, controllers.NewFieldError(controllers.FieldInvalid, "scopes", "unknown scope "+scope))
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		errs = append(errs, controllers.NewFieldError(controllers.FieldOutOfRange, "expires_at", "expires_at must be in the future"))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,27 @@
+package apikeycontroller
+
+import (
+	"project/internal/app/controllers"
+	"testing"
+	"time"
+)
+
+func TestValidatePostAPIKey(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+
+	if err := validatePostAPIKey(postAPIKeyRequest{Name: "recommendations", Scopes: []string{"banners:read", "banners:write:7"}}, now); err != nil {
+		t.Errorf("expected valid request, got %v", err)
+	}
+
+	err := validatePostAPIKey(postAPIKeyRequest{Scopes: []string{"banners:admin"}, ExpiresAt: &past}, now)
+	fields := controllers.FieldErrors(err)
+	if len(fields) != 3 {
+		t.Fatalf("expected name, scopes and expires_at errors, got %+v", fields)
+	}
+	for i, field := range []string{"name", "scopes", "expires_at"} {
+		if fields[i].Field != field {
+			t.Errorf("expected error %d for %s, got %s", i, field, fields[i].Field)
+		}
+	}
+}
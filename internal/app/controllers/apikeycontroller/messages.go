@@ -0,0 +1,3 @@
+package apikeycontroller
+
+const APIKeyNotFound = "API-ключ не найден"
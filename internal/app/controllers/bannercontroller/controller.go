@@ -6,10 +6,19 @@ import (
 
 type bannerService interface {
 	userBannerGetter
+	bannerGetter
 	bannerDeleter
+	bannerRestorer
+	bannersDeleter
+	jobGetter
+	featureSchemaManager
 	bannerSaver
+	bulkBannerSaver
+	bannerExporter
 	bannersGetter
 	bannerUpdater
+	bannerVersionsGetter
+	bannerVersionActivator
 }
 
 type controller struct {
@@ -0,0 +1,90 @@
+package bannercontroller
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/app/models"
+	"project/internal/logger"
+	"strings"
+)
+
+// fakeBannerService answers the handlers under test, the rest of
+// bannerService panics if called.
+type fakeBannerService struct {
+	bannerService
+	banners     map[int]models.BannerDetails
+	saved       []models.Banner
+	activateErr error
+	jobs        map[int]models.DeleteJob
+}
+
+func (f *fakeBannerService) GetBanner(ctx context.Context, id int) (models.BannerDetails, error) {
+	banner, ok := f.banners[id]
+	if !ok {
+		return models.BannerDetails{}, models.BannerNotFound
+	}
+	return banner, nil
+}
+
+func (f *fakeBannerService) SaveBanner(ctx context.Context, banner models.Banner, author string) (int, error) {
+	f.saved = append(f.saved, banner)
+	return len(f.saved), nil
+}
+
+func (f *fakeBannerService) ActivateBannerVersion(ctx context.Context, bannerID int, version int, author string) error {
+	return f.activateErr
+}
+
+func (f *fakeBannerService) UpdateBanner(ctx context.Context, bannerID int, patch models.BannerPatch, ifMatch []int, principal models.Principal) (models.Banner, error) {
+	banner, ok := f.banners[bannerID]
+	if !ok {
+		return models.Banner{}, models.BannerNotFound
+	}
+	if !banner.MatchesVersion(ifMatch) {
+		return models.Banner{}, models.VersionMismatch
+	}
+	return banner.Banner, nil
+}
+
+func (f *fakeBannerService) GetDeleteJob(ctx context.Context, id int) (models.DeleteJob, error) {
+	job, ok := f.jobs[id]
+	if !ok {
+		return models.DeleteJob{}, models.JobNotFound
+	}
+	return job, nil
+}
+
+// serve runs the handler at route for a request made by principal.
+func serve(handler gin.HandlerFunc, method string, route string, target string, principal models.Principal, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return serveRequest(handler, route, req, principal)
+}
+
+// serveRequest is serve for a request built by the caller.
+func serveRequest(handler gin.HandlerFunc, route string, req *http.Request, principal models.Principal) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(req.Method, route, func(ctx *gin.Context) {
+		ctx.Set("principal", principal)
+		ctx.Set("admin", principal.Admin)
+		ctx.Set("subject", principal.Subject)
+	}, handler)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func newTestController(bs *fakeBannerService) *controller {
+	return New(logger.New(), bs)
+}
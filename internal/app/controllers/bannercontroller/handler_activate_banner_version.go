@@ -0,0 +1,62 @@
+package bannercontroller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type bannerVersionActivator interface {
+	ActivateBannerVersion(ctx context.Context, bannerID int, version int, author string) error
+}
+
+func (c *controller) ActivateVersionHandler() gin.HandlerFunc {
+	const op = "bannercontroller.ActivateVersionHandler"
+	return func(ctx *gin.Context) {
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		version, err := controllers.ParseQueryParam(ctx, "version", true, 0, controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse version: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		// The revision may belong to another feature, so only unscoped
+		// editors may activate it.
+		if !c.authorize(ctx, models.ScopeBannersWrite) {
+			return
+		}
+
+		err = c.bs.ActivateBannerVersion(ctx, id, version, controllers.GetSubject(ctx))
+		if errors.Is(err, models.BannerNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, BannerNotFound)
+			return
+		}
+
+		if errors.Is(err, models.RevisionNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, VersionNotFound)
+			return
+		}
+
+		if respondConflict(ctx, err) || respondInvalidContent(ctx, err) {
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to activate banner version: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"status": controllers.OK})
+	}
+}
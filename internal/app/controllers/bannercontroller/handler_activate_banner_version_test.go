@@ -0,0 +1,21 @@
+package bannercontroller
+
+import (
+	"net/http"
+	"project/internal/app/models"
+	"testing"
+)
+
+func TestActivateVersionHandler_InvalidContent(t *testing.T) {
+	bs := &fakeBannerService{activateErr: &models.ContentValidationError{
+		FeatureID: 1,
+		Errors:    []models.ContentError{{Path: "/url", Message: "missing properties: 'url'"}},
+	}}
+	c := newTestController(bs)
+	editor := models.Principal{Subject: "editor", Scopes: []string{models.ScopeBannersWrite}}
+
+	rec := serve(c.ActivateVersionHandler(), http.MethodPut, "/banner/versions/:id/activate", "/banner/versions/1/activate?version=1", editor, "")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for content failing the schema, got %d: %s", rec.Code, rec.Body)
+	}
+}
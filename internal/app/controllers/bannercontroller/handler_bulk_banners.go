@@ -0,0 +1,266 @@
+package bannercontroller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+const (
+	maxBulkBanners = 1000
+	// maxBulkBodyBytes caps the body before it is decoded.
+	maxBulkBodyBytes = 16 << 20
+)
+
+type bulkBannerSaver interface {
+	SaveBanners(ctx context.Context, banners []models.Banner, author string) ([]int, error)
+}
+
+type bannerExporter interface {
+	ExportBanners(ctx context.Context, fn func(models.Banner) error) error
+}
+
+// bulkItemError is one rejected banner of a bulk import, Index is its position
+// in the request body. A clash is reported as BannerID for a stored banner and
+// as DuplicateOf, the position of the other banner, within the import.
+type bulkItemError struct {
+	Index int `json:"index"`
+	controllers.ErrorResponse
+	BannerID    int  `json:"banner_id,omitempty"`
+	DuplicateOf *int `json:"duplicate_of,omitempty"`
+}
+
+type bulkErrorResponse struct {
+	controllers.ErrorResponse
+	Items []bulkItemError `json:"items"`
+}
+
+// BulkPostHandler creates banners from a JSON array or an NDJSON stream. Either
+// all of them are created or none, and every rejected banner is reported.
+func (c *controller) BulkPostHandler() gin.HandlerFunc {
+	const op = "bannercontroller.BulkPostHandler"
+	return func(ctx *gin.Context) {
+		items, err := decodeBulkBody(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBulkBodyBytes), maxBulkBanners)
+		if err == nil && len(items) == 0 {
+			err = bulkSizeOutOfRange
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.log.Errorf("%s : Body exceeds %d bytes", op, tooLarge.Limit)
+			controllers.RespondError(ctx, http.StatusRequestEntityTooLarge, controllers.CodeInvalidRequest, controllers.BadRequest,
+				*controllers.NewFieldError(controllers.FieldOutOfRange, "body", fmt.Sprintf("body must not exceed %d bytes", tooLarge.Limit)))
+			return
+		}
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse body of %d banners: %v", op, len(items), err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		banners := make([]models.Banner, 0, len(items))
+		var rejected []bulkItemError
+		for i, item := range items {
+			banner, err := parseBulkBanner(item)
+			if err != nil {
+				rejected = append(rejected, bulkItemError{
+					Index: i,
+					ErrorResponse: controllers.ErrorResponse{
+						Error:   controllers.BadRequest,
+						Code:    controllers.CodeInvalidRequest,
+						Details: controllers.FieldErrors(err),
+					},
+				})
+				continue
+			}
+			banners = append(banners, banner)
+		}
+
+		if len(rejected) > 0 {
+			respondBulkError(ctx, http.StatusBadRequest, controllers.CodeInvalidRequest, controllers.BadRequest, rejected)
+			return
+		}
+
+		featureIDs := make([]int, len(banners))
+		for i, banner := range banners {
+			featureIDs[i] = banner.FeatureID
+		}
+		if !c.authorize(ctx, models.ScopeBannersWrite, featureIDs...) {
+			return
+		}
+
+		ids, err := c.bs.SaveBanners(ctx, banners, controllers.GetSubject(ctx))
+		var bulkErr *models.BulkImportError
+		if errors.As(err, &bulkErr) {
+			for _, item := range bulkErr.Items {
+				rejected = append(rejected, toBulkItemError(item))
+			}
+
+			// Content is checked before anything is written, so a rejection
+			// is either all schema violations or all tag conflicts.
+			var invalid *models.ContentValidationError
+			if errors.As(bulkErr.Items[0].Err, &invalid) {
+				respondBulkError(ctx, http.StatusUnprocessableEntity, controllers.CodeInvalidContent, ContentInvalid, rejected)
+				return
+			}
+			respondBulkError(ctx, http.StatusConflict, controllers.CodeConflict, BannerConflict, rejected)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to save banners: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusCreated, gin.H{"banner_ids": ids})
+	}
+}
+
+// ExportHandler streams every banner as NDJSON, one banner per line.
+func (c *controller) ExportHandler() gin.HandlerFunc {
+	const op = "bannercontroller.ExportHandler"
+	return func(ctx *gin.Context) {
+		if !c.authorize(ctx, models.ScopeBannersRead) {
+			return
+		}
+
+		ctx.Header("Content-Type", "application/x-ndjson")
+		ctx.Status(http.StatusOK)
+
+		enc := json.NewEncoder(ctx.Writer)
+		err := c.bs.ExportBanners(ctx, func(banner models.Banner) error {
+			return enc.Encode(&banner)
+		})
+		if err != nil {
+			// The status is already sent, the client sees a truncated stream.
+			c.log.Errorf("%s : Failed to export banners: %s", op, err)
+		}
+	}
+}
+
+var bulkSizeOutOfRange = controllers.NewFieldError(controllers.FieldOutOfRange, "body", fmt.Sprintf("body must hold 1 to %d banners", maxBulkBanners))
+
+var bulkTrailingData = controllers.NewFieldError(controllers.FieldMalformed, "body", "body must end with the banners array")
+
+// decodeBulkBody splits the body into raw banners. A body starting with '[' is
+// a JSON array and nothing may follow it, anything else is read as a stream of
+// JSON values (NDJSON). Banners are counted while they are read, decoding
+// stops with bulkSizeOutOfRange at the first one past limit.
+func decodeBulkBody(body io.Reader, limit int) ([]json.RawMessage, error) {
+	r := bufio.NewReader(body)
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if b[0] != ' ' && b[0] != '\t' && b[0] != '\r' && b[0] != '\n' {
+			break
+		}
+		r.ReadByte()
+	}
+
+	dec := json.NewDecoder(r)
+	var items []json.RawMessage
+	if b, _ := r.Peek(1); b[0] == '[' {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		for dec.More() {
+			if len(items) == limit {
+				return nil, bulkSizeOutOfRange
+			}
+			var item json.RawMessage
+			if err := dec.Decode(&item); err != nil {
+				return nil, fmt.Errorf("item %d: %w", len(items), err)
+			}
+			items = append(items, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+
+		// Nothing but whitespace may follow the array.
+		if dec.More() {
+			return nil, bulkTrailingData
+		}
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err == nil {
+			err = bulkTrailingData
+		}
+		return nil, err
+	}
+
+	for {
+		var item json.RawMessage
+		err := dec.Decode(&item)
+		if errors.Is(err, io.EOF) {
+			return items, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(items)+1, err)
+		}
+		if len(items) == limit {
+			return nil, bulkSizeOutOfRange
+		}
+		items = append(items, item)
+	}
+}
+
+func parseBulkBanner(item json.RawMessage) (models.Banner, error) {
+	var req postBannerRequest
+	if err := json.Unmarshal(item, &req); err != nil {
+		return models.Banner{}, err
+	}
+
+	if err := req.validate(); err != nil {
+		return models.Banner{}, err
+	}
+
+	return req.banner(), nil
+}
+
+func toBulkItemError(item *models.BulkItemError) bulkItemError {
+	res := bulkItemError{
+		Index:         item.Index,
+		ErrorResponse: controllers.ErrorResponse{Error: item.Err.Error(), Code: controllers.CodeInvalidRequest},
+	}
+	var conflict *models.BannerConflictError
+	if errors.As(item.Err, &conflict) {
+		res.Error = BannerConflict
+		res.Code = controllers.CodeConflict
+		res.BannerID = conflict.BannerID
+	}
+	var duplicate *models.BulkDuplicateError
+	if errors.As(item.Err, &duplicate) {
+		res.Error = BannerConflict
+		res.Code = controllers.CodeConflict
+		res.DuplicateOf = &duplicate.Index
+	}
+	var invalid *models.ContentValidationError
+	if errors.As(item.Err, &invalid) {
+		res.Error = ContentInvalid
+		res.Code = controllers.CodeInvalidContent
+		res.Details = contentFieldErrors(invalid)
+	}
+	return res
+}
+
+func respondBulkError(ctx *gin.Context, status int, code string, message string, items []bulkItemError) {
+	ctx.AbortWithStatusJSON(status, &bulkErrorResponse{
+		ErrorResponse: controllers.ErrorResponse{Error: message, Code: code},
+		Items:         items,
+	})
+}
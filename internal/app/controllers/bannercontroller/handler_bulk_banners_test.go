@@ -0,0 +1,63 @@
+package bannercontroller
+
+import (
+	"errors"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBulkBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "array", body: ` [{"feature_id": 1}, {"feature_id": 2}]`, want: 2},
+		{name: "array with trailing whitespace", body: "[{\"feature_id\": 1}]\n\n", want: 1},
+		{name: "ndjson", body: "{\"feature_id\": 1}\n{\"feature_id\": 2}\n{\"feature_id\": 3}\n", want: 3},
+		{name: "empty", body: "\n", want: 0},
+	}
+
+	for _, tt := range tests {
+		items, err := decodeBulkBody(strings.NewReader(tt.body), maxBulkBanners)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if len(items) != tt.want {
+			t.Errorf("%s: got %d items, want %d", tt.name, len(items), tt.want)
+		}
+	}
+
+	if _, err := decodeBulkBody(strings.NewReader("{\"feature_id\": 1}\n{broken"), maxBulkBanners); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+
+	for _, body := range []string{`[{}] {}`, `[{}]]`, "[{}]\n[{}]"} {
+		if _, err := decodeBulkBody(strings.NewReader(body), maxBulkBanners); err == nil {
+			t.Errorf("expected %q to be rejected for data after the array", body)
+		}
+	}
+
+	for _, body := range []string{`[{}, {}, {}]`, "{}\n{}\n{}\n"} {
+		if _, err := decodeBulkBody(strings.NewReader(body), 2); !errors.Is(err, bulkSizeOutOfRange) {
+			t.Errorf("expected %q to be rejected past 2 banners, got %v", body, err)
+		}
+	}
+}
+
+func TestToBulkItemError_Duplicate(t *testing.T) {
+	res := toBulkItemError(&models.BulkItemError{Index: 3, Err: &models.BulkDuplicateError{Index: 0}})
+	if res.Code != controllers.CodeConflict || res.DuplicateOf == nil || *res.DuplicateOf != 0 || res.BannerID != 0 {
+		t.Errorf("expected banner 3 to be reported as a duplicate of banner 0, got %+v", res)
+	}
+}
+
+func TestParseBulkBanner_RequiredFields(t *testing.T) {
+	_, err := parseBulkBanner([]byte(`{"feature_id": 1, "tag_ids": []}`))
+	if fields := controllers.FieldErrors(err); len(fields) != 1 || fields[0].Field != "tag_ids" {
+		t.Errorf("expected tag_ids to be reported missing, got %v", fields)
+	}
+}
@@ -2,35 +2,60 @@ package bannercontroller
 
 import (
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"project/internal/app/controllers"
+	"project/internal/app/models"
 )
 
 type bannerDeleter interface {
-	DeleteBanner(ctx context.Context, bannerID int) (bool, error)
+	DeleteBanner(ctx context.Context, bannerID int, ifMatch []int, principal models.Principal) error
 }
 
 func (c *controller) DeleteHandler() gin.HandlerFunc {
 	const op = "bannercontroller.DeleteBannerHandler"
 	return func(ctx *gin.Context) {
-		id, err := controllers.ParseQueryParam(ctx, "id", true, -1, controllers.ConvToInt)
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
 		if err != nil {
 			c.log.Errorf("%s: Failed to parse param: %v", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
-		ok, err := c.bs.DeleteBanner(ctx, id)
+		ifMatch, err := parseIfMatch(ctx)
+		if respondPrecondition(ctx, err) {
+			return
+		}
 		if err != nil {
-			c.log.Errorf("%s: Failed to delete banner: %v", op, err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": controllers.InternalServerError})
+			c.log.Errorf("%s: Failed to parse If-Match: %v", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		principal, err := controllers.GetPrincipal(ctx)
+		if err != nil {
+			c.log.Errorf("%s: Failed to get principal: %v", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		// The feature grant is checked by the service under the row lock.
+		err = c.bs.DeleteBanner(ctx, id, ifMatch, principal)
+		if respondForbidden(ctx, err) || respondPrecondition(ctx, err) {
 			return
 		}
 
-		if !ok {
+		if errors.Is(err, models.BannerNotFound) {
 			c.log.Errorf("%s: Not found banner: %d", op, id)
-			ctx.JSON(http.StatusNotFound, gin.H{"error": BannerNotFound})
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, BannerNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s: Failed to delete banner: %v", op, err)
+			controllers.RespondInternalError(ctx)
+			return
 		}
 
 		ctx.JSON(http.StatusNoContent, gin.H{"message": BannerDeleted})
@@ -0,0 +1,59 @@
+package bannercontroller
+
+import (
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type bannersDeleter interface {
+	EnqueueDeleteBanners(ctx context.Context, featureID *int, tagID *int) (models.DeleteJob, error)
+}
+
+// DeleteManyHandler enqueues deletion of every banner with the feature_id
+// and/or tag_id and answers 202 with the job to poll at /jobs/:id.
+func (c *controller) DeleteManyHandler() gin.HandlerFunc {
+	const op = "bannercontroller.DeleteManyHandler"
+	return func(ctx *gin.Context) {
+		featureID, err := controllers.ParseOptionalQueryParam(ctx, "feature_id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse feature_id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		tagID, err := controllers.ParseOptionalQueryParam(ctx, "tag_id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse tag_id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		if featureID == nil && tagID == nil {
+			c.log.Errorf("%s : Neither feature_id nor tag_id is set", op)
+			controllers.RespondBadRequest(ctx, controllers.NewFieldError(controllers.FieldRequired, "feature_id", "feature_id or tag_id is required"))
+			return
+		}
+
+		var featureIDs []int
+		if featureID != nil {
+			featureIDs = append(featureIDs, *featureID)
+		}
+		if !c.authorize(ctx, models.ScopeBannersDelete, featureIDs...) {
+			return
+		}
+
+		job, err := c.bs.EnqueueDeleteBanners(ctx, featureID, tagID)
+		if err != nil {
+			c.log.Errorf("%s : Failed to enqueue delete job: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.Header("Location", fmt.Sprintf("/jobs/%d", job.ID))
+		ctx.JSON(http.StatusAccepted, &job)
+	}
+}
@@ -0,0 +1,104 @@
+package bannercontroller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type featureSchemaManager interface {
+	GetFeatureSchema(ctx context.Context, featureID int) (models.FeatureSchema, error)
+	SetFeatureSchema(ctx context.Context, featureID int, schema json.RawMessage) (models.FeatureSchema, error)
+	DeleteFeatureSchema(ctx context.Context, featureID int) error
+}
+
+func (c *controller) GetFeatureSchemaHandler() gin.HandlerFunc {
+	const op = "bannercontroller.GetFeatureSchemaHandler"
+	return func(ctx *gin.Context) {
+		featureID, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		schema, err := c.bs.GetFeatureSchema(ctx, featureID)
+		if errors.Is(err, models.SchemaNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, SchemaNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to get feature schema: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &schema)
+	}
+}
+
+// PutFeatureSchemaHandler takes the JSON Schema itself as the request body.
+func (c *controller) PutFeatureSchemaHandler() gin.HandlerFunc {
+	const op = "bannercontroller.PutFeatureSchemaHandler"
+	return func(ctx *gin.Context) {
+		featureID, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		var schema json.RawMessage
+		if err := ctx.ShouldBindJSON(&schema); err != nil {
+			c.log.Errorf("%s : Failed to parse body: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		saved, err := c.bs.SetFeatureSchema(ctx, featureID, schema)
+		if errors.Is(err, models.InvalidSchema) {
+			controllers.RespondError(ctx, http.StatusBadRequest, controllers.CodeInvalidRequest, InvalidSchema,
+				*controllers.NewFieldError(controllers.FieldInvalid, "body", err.Error()))
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to save feature schema: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &saved)
+	}
+}
+
+func (c *controller) DeleteFeatureSchemaHandler() gin.HandlerFunc {
+	const op = "bannercontroller.DeleteFeatureSchemaHandler"
+	return func(ctx *gin.Context) {
+		featureID, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		err = c.bs.DeleteFeatureSchema(ctx, featureID)
+		if errors.Is(err, models.SchemaNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, SchemaNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to delete feature schema: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
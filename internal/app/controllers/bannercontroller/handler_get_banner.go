@@ -0,0 +1,53 @@
+package bannercontroller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type bannerGetter interface {
+	GetBanner(ctx context.Context, bannerID int) (models.BannerDetails, error)
+}
+
+func (c *controller) GetBannerHandler() gin.HandlerFunc {
+	const op = "bannercontroller.GetBannerHandler"
+	return func(ctx *gin.Context) {
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		principal, err := controllers.GetPrincipal(ctx)
+		if err != nil {
+			c.log.Errorf("%s : Failed to get principal: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		// Banners of features the caller may not read are reported missing,
+		// so their ids cannot be probed.
+		banner, err := c.bs.GetBanner(ctx, id)
+		if err == nil && !principal.HasFeatureScope(models.ScopeBannersRead, banner.FeatureID) {
+			err = models.BannerNotFound
+		}
+		if errors.Is(err, models.BannerNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, BannerNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to get banner: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.Header("ETag", banner.ETag)
+		ctx.IndentedJSON(http.StatusOK, &banner)
+	}
+}
@@ -0,0 +1,69 @@
+package bannercontroller
+
+import (
+	"encoding/json"
+	"net/http"
+	"project/internal/app/models"
+	"testing"
+	"time"
+)
+
+func TestGetBannerHandler(t *testing.T) {
+	created := time.Date(2024, 4, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	bs := &fakeBannerService{banners: map[int]models.BannerDetails{
+		1: {
+			Banner:       models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 7, Version: 3, ETag: models.VersionETag(3), CreatedAt: created, UpdatedAt: updated},
+			Revision:     3,
+			RevisionBy:   "editor",
+			RevisionTime: &updated,
+		},
+	}}
+	c := newTestController(bs)
+	admin := models.Principal{Admin: true}
+
+	rec := serve(c.GetBannerHandler(), http.MethodGet, "/banner/:id", "/banner/1", admin, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if etag := rec.Header().Get("ETag"); etag != models.VersionETag(3) {
+		t.Errorf("expected ETag %s, got %q", models.VersionETag(3), etag)
+	}
+
+	var got models.BannerDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Revision != 3 || got.RevisionBy != "editor" || got.RevisionTime == nil || !got.RevisionTime.Equal(updated) {
+		t.Errorf("unexpected revision %d by %q at %v", got.Revision, got.RevisionBy, got.RevisionTime)
+	}
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("unexpected timestamps %v and %v", got.CreatedAt, got.UpdatedAt)
+	}
+
+	if rec := serve(c.GetBannerHandler(), http.MethodGet, "/banner/:id", "/banner/2", admin, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing banner, got %d", rec.Code)
+	}
+}
+
+func TestGetBannerHandler_OtherFeature(t *testing.T) {
+	bs := &fakeBannerService{banners: map[int]models.BannerDetails{
+		1: {Banner: models.Banner{ID: 1, FeatureID: 7}},
+		2: {Banner: models.Banner{ID: 2, FeatureID: 8}},
+	}}
+	c := newTestController(bs)
+	reader := models.Principal{Scopes: []string{models.FeatureScope(models.ScopeBannersRead, 7)}}
+
+	if rec := serve(c.GetBannerHandler(), http.MethodGet, "/banner/:id", "/banner/1", reader, ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a banner of feature 7, got %d", rec.Code)
+	}
+
+	existing := serve(c.GetBannerHandler(), http.MethodGet, "/banner/:id", "/banner/2", reader, "")
+	missing := serve(c.GetBannerHandler(), http.MethodGet, "/banner/:id", "/banner/3", reader, "")
+	if existing.Code != http.StatusNotFound || missing.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for banners of other features and missing ones, got %d and %d", existing.Code, missing.Code)
+	}
+	if existing.Body.String() != missing.Body.String() {
+		t.Errorf("expected the same body for both, got %s and %s", existing.Body, missing.Body)
+	}
+}
@@ -0,0 +1,44 @@
+package bannercontroller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type bannerVersionsGetter interface {
+	GetBannerVersions(ctx context.Context, bannerID int) ([]models.BannerRevision, error)
+}
+
+func (c *controller) GetVersionsHandler() gin.HandlerFunc {
+	const op = "bannercontroller.GetVersionsHandler"
+	return func(ctx *gin.Context) {
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		if !c.authorizeBanner(ctx, models.ScopeBannersRead, id) {
+			return
+		}
+
+		revisions, err := c.bs.GetBannerVersions(ctx, id)
+		if errors.Is(err, models.BannerNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, BannerNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to get banner versions: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.IndentedJSON(http.StatusOK, &revisions)
+	}
+}
@@ -2,54 +2,154 @@ package bannercontroller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"project/internal/app/controllers"
 	"project/internal/app/models"
+	"strings"
 )
 
+const maxLimit = 100
+
+// envelopeMediaType is the Accept value that opts into the paginated envelope,
+// the same as passing envelope=true.
+const envelopeMediaType = "application/vnd.banners.v2+json"
+
 type bannersGetter interface {
-	GetBanners(ctx context.Context, featureID int, tagID int, limit int, offset int) ([]models.Banner, error)
+	GetBanners(ctx context.Context, filter models.BannerFilter) ([]models.Banner, error)
+	GetBannersPage(ctx context.Context, filter models.BannerFilter) (models.BannerPage, error)
+	GetBannersWithTotal(ctx context.Context, filter models.BannerFilter) ([]models.Banner, int, error)
+}
+
+type bannersEnvelopeResponse struct {
+	Items  []models.Banner `json:"items"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+type bannersPageResponse struct {
+	Items      []models.Banner `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	PrevCursor string          `json:"prev_cursor,omitempty"`
 }
 
 func (c *controller) GetHandler() gin.HandlerFunc {
 	const op = "bannercontroller.GetHandler"
 	return func(ctx *gin.Context) {
-		featureID, err := controllers.ParseQueryParam(ctx, "feature_id", false, -1, controllers.ConvToInt)
+		filter, err := parseBannerFilter(ctx)
 		if err != nil {
 			c.log.Errorf("%s Failed to parse params: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
-		tagID, err := controllers.ParseQueryParam(ctx, "tag_id", false, -1, controllers.ConvToInt)
-		if err != nil {
-			c.log.Errorf("%s Failed to parse params: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+		// Feature-scoped readers have to filter by one of their features.
+		var featureIDs []int
+		if filter.FeatureID != nil {
+			featureIDs = append(featureIDs, *filter.FeatureID)
+		}
+		if !c.authorize(ctx, models.ScopeBannersRead, featureIDs...) {
 			return
 		}
 
-		limit, err := controllers.ParseQueryParam(ctx, "limit", false, 10, controllers.ConvToInt)
-		if err != nil {
-			c.log.Errorf("%s Failed to parse params: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+		if filter.Cursor != nil || ctx.Query("pagination") == "cursor" {
+			page, err := c.bs.GetBannersPage(ctx, filter)
+			if err != nil {
+				c.log.Errorf("%s Failed to get banners page: %s", op, err)
+				controllers.RespondInternalError(ctx)
+				return
+			}
+
+			resp := bannersPageResponse{Items: page.Items}
+			if page.Next != nil {
+				resp.NextCursor = page.Next.Encode()
+			}
+			if page.Prev != nil {
+				resp.PrevCursor = page.Prev.Encode()
+			}
+			ctx.IndentedJSON(http.StatusOK, &resp)
 			return
 		}
 
-		offset, err := controllers.ParseQueryParam(ctx, "offset", false, 0, controllers.ConvToInt)
-		if err != nil {
-			c.log.Errorf("%s Failed to parse params: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+		if wantsEnvelope(ctx) {
+			banners, total, err := c.bs.GetBannersWithTotal(ctx, filter)
+			if err != nil {
+				c.log.Errorf("%s Failed to get banners: %s", op, err)
+				controllers.RespondInternalError(ctx)
+				return
+			}
+
+			ctx.IndentedJSON(http.StatusOK, &bannersEnvelopeResponse{
+				Items:  banners,
+				Total:  total,
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+			})
 			return
 		}
 
-		banners, err := c.bs.GetBanners(ctx, featureID, tagID, limit, offset)
+		banners, err := c.bs.GetBanners(ctx, filter)
 		if err != nil {
 			c.log.Errorf("%s Failed to get banners: %s", op, err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": controllers.InternalServerError})
+			controllers.RespondInternalError(ctx)
 			return
 		}
 
 		ctx.IndentedJSON(http.StatusOK, &banners)
 	}
 }
+
+// parseBannerFilter reads the list query parameters, reporting every invalid
+// one at once (errors.Join of *controllers.FieldError).
+func parseBannerFilter(ctx *gin.Context) (filter models.BannerFilter, err error) {
+	var errs []error
+	collect := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	filter.TagID, err = controllers.ParseOptionalQueryParam(ctx, "tag_id", controllers.ConvToInt)
+	collect(err)
+	filter.FeatureID, err = controllers.ParseOptionalQueryParam(ctx, "feature_id", controllers.ConvToInt)
+	collect(err)
+	filter.IsActive, err = controllers.ParseOptionalQueryParam(ctx, "is_active", controllers.ConvToBool)
+	collect(err)
+	filter.Schedule, err = controllers.ParseQueryParam(ctx, "schedule", false, models.ScheduleAny, models.ParseScheduleStatus)
+	collect(err)
+	filter.CreatedFrom, err = controllers.ParseOptionalQueryParam(ctx, "created_from", controllers.ConvToTime)
+	collect(err)
+	filter.CreatedTo, err = controllers.ParseOptionalQueryParam(ctx, "created_to", controllers.ConvToTime)
+	collect(err)
+	filter.UpdatedFrom, err = controllers.ParseOptionalQueryParam(ctx, "updated_from", controllers.ConvToTime)
+	collect(err)
+	filter.UpdatedTo, err = controllers.ParseOptionalQueryParam(ctx, "updated_to", controllers.ConvToTime)
+	collect(err)
+	filter.ContentKey = ctx.Query("content_key")
+
+	filter.Cursor, err = controllers.ParseOptionalQueryParam(ctx, "cursor", models.DecodeBannerCursor)
+	collect(err)
+
+	filter.Limit, err = controllers.ParseQueryParam(ctx, "limit", false, 10, controllers.ConvToInt)
+	collect(err)
+	if err == nil && (filter.Limit < 1 || filter.Limit > maxLimit) {
+		collect(controllers.NewFieldError(controllers.FieldOutOfRange, "limit", fmt.Sprintf("limit must be between 1 and %d", maxLimit)))
+	}
+	filter.Offset, err = controllers.ParseQueryParam(ctx, "offset", false, 0, controllers.ConvToInt)
+	collect(err)
+	if err == nil && filter.Offset < 0 {
+		collect(controllers.NewFieldError(controllers.FieldOutOfRange, "offset", "offset must not be negative"))
+	}
+
+	return filter, errors.Join(errs...)
+}
+
+func wantsEnvelope(ctx *gin.Context) bool {
+	if envelope, err := controllers.ConvToBool(ctx.Query("envelope")); err == nil && envelope {
+		return true
+	}
+	return strings.Contains(ctx.GetHeader("Accept"), envelopeMediaType)
+}
@@ -0,0 +1,50 @@
+package bannercontroller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type jobGetter interface {
+	GetDeleteJob(ctx context.Context, jobID int) (models.DeleteJob, error)
+}
+
+// GetJobHandler answers the job to callers allowed to enqueue it, the delete
+// scope is checked against the job's feature like DeleteManyHandler does.
+func (c *controller) GetJobHandler() gin.HandlerFunc {
+	const op = "bannercontroller.GetJobHandler"
+	return func(ctx *gin.Context) {
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		job, err := c.bs.GetDeleteJob(ctx, id)
+		if errors.Is(err, models.JobNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, JobNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to get job: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		var featureIDs []int
+		if job.FeatureID != nil {
+			featureIDs = append(featureIDs, *job.FeatureID)
+		}
+		if !c.authorize(ctx, models.ScopeBannersDelete, featureIDs...) {
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &job)
+	}
+}
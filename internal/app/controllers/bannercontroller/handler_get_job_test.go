@@ -0,0 +1,37 @@
+package bannercontroller
+
+import (
+	"net/http"
+	"project/internal/app/models"
+	"testing"
+)
+
+func TestController_GetJobHandler(t *testing.T) {
+	featureID := 7
+	bs := &fakeBannerService{jobs: map[int]models.DeleteJob{
+		1: {ID: 1, FeatureID: &featureID, Status: models.JobRunning},
+		2: {ID: 2, TagID: &featureID, Status: models.JobRunning},
+	}}
+	c := newTestController(bs)
+	scoped := models.Principal{Scopes: []string{models.FeatureScope(models.ScopeBannersDelete, 7)}}
+
+	tests := []struct {
+		name      string
+		principal models.Principal
+		target    string
+		want      int
+	}{
+		{name: "scoped caller polls its own job", principal: scoped, target: "/jobs/1", want: http.StatusOK},
+		{name: "scoped caller polls a job for all features", principal: scoped, target: "/jobs/2", want: http.StatusForbidden},
+		{name: "other feature", principal: models.Principal{Scopes: []string{models.FeatureScope(models.ScopeBannersDelete, 8)}}, target: "/jobs/1", want: http.StatusForbidden},
+		{name: "unscoped", principal: models.Principal{Scopes: []string{models.ScopeBannersDelete}}, target: "/jobs/2", want: http.StatusOK},
+		{name: "missing", principal: scoped, target: "/jobs/3", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rec := serve(c.GetJobHandler(), http.MethodGet, "/jobs/:id", tt.target, tt.principal, "")
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, rec.Code, rec.Body)
+		}
+	}
+}
@@ -9,57 +9,97 @@ import (
 	"project/internal/app/models"
 )
 
+var tagIDRequired = controllers.NewFieldError(controllers.FieldRequired, "tag_id", "tag_id is required, the token has no tags")
+
 type userBannerGetter interface {
-	GetUserBanner(ctx context.Context, tagID int, featureID int, useLastRevision bool) (models.Banner, error)
+	GetUserBanner(ctx context.Context, tagID int, featureID int, locales []string, useLastRevision bool, admin bool) (models.Banner, error)
 }
 
 func (c *controller) GetUserBannerHandler() gin.HandlerFunc {
 	const op = "bannercontroller.GetUserBanner"
 	return func(ctx *gin.Context) {
-		tagID, err := controllers.ParseQueryParam(ctx, "tag_id", true, -1, controllers.ConvToInt)
+		tagID, err := controllers.ParseOptionalQueryParam(ctx, "tag_id", controllers.ConvToInt)
 		if err != nil {
 			c.log.Errorf("%s : Failed to parse tag_id %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
 		featureID, err := controllers.ParseQueryParam(ctx, "feature_id", true, -1, controllers.ConvToInt)
 		if err != nil {
 			c.log.Errorf("%s Failed to parse feature_id: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
 		useLastRevision, err := controllers.ParseQueryParam(ctx, "use_last_revision", false, false, controllers.ConvToBool)
 		if err != nil {
 			c.log.Errorf("%s Failed to parse use_last_revision: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
-		banner, err := c.bs.GetUserBanner(ctx, tagID, featureID, useLastRevision)
-		if errors.Is(err, models.BannerNotFound) {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": BannerNotFound})
+		principal, err := controllers.GetPrincipal(ctx)
+		if err != nil {
+			c.log.Errorf("%s : Failed to get principal: %s", op, err)
+			controllers.RespondInternalError(ctx)
 			return
 		}
 
-		if err != nil {
-			c.log.Errorf("%s Failed to get banner: %s", op, err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": controllers.InternalServerError})
+		if tagID == nil && len(principal.TagIDs) == 0 {
+			controllers.RespondBadRequest(ctx, tagIDRequired)
+			return
+		}
+
+		tagIDs, tagErr := userTagIDs(principal, tagID)
+		if tagErr != nil {
+			controllers.RespondError(ctx, http.StatusForbidden, controllers.CodeForbidden, TagForbidden, *tagErr)
+			return
+		}
+
+		banner, err := c.getUserBanner(ctx, tagIDs, featureID, controllers.PreferredLocales(ctx), useLastRevision, principal.Admin)
+		if errors.Is(err, models.BannerNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, BannerNotFound)
 			return
 		}
 
-		admin, err := controllers.CheckAdminStatus(ctx)
 		if err != nil {
-			c.log.Errorf("%s : Failed to check admin status: %s", op, err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": controllers.InternalServerError})
+			c.log.Errorf("%s Failed to get banner: %s", op, err)
+			controllers.RespondInternalError(ctx)
 			return
 		}
 
-		if admin {
+		ctx.Header("Content-Language", banner.Locale)
+		ctx.Header("Vary", "Accept-Language")
+		if principal.Admin {
 			ctx.IndentedJSON(http.StatusOK, banner)
 		} else {
 			ctx.IndentedJSON(http.StatusOK, banner.Content)
 		}
 	}
 }
+
+// userTagIDs returns the tags to look the banner up for. A tag_id is only
+// honoured if the caller may see it, without one every tag of the user is
+// tried in the order the token lists them.
+func userTagIDs(principal models.Principal, tagID *int) ([]int, *controllers.FieldError) {
+	if tagID != nil {
+		if !principal.CanSeeTag(*tagID) {
+			return nil, controllers.NewFieldError(controllers.FieldInvalid, "tag_id", "tag_id is not one of the user tags")
+		}
+		return []int{*tagID}, nil
+	}
+
+	return principal.TagIDs, nil
+}
+
+// getUserBanner returns the banner of the first tag that has one for the feature.
+func (c *controller) getUserBanner(ctx context.Context, tagIDs []int, featureID int, locales []string, useLastRevision bool, admin bool) (models.Banner, error) {
+	for _, tagID := range tagIDs {
+		banner, err := c.bs.GetUserBanner(ctx, tagID, featureID, locales, useLastRevision, admin)
+		if !errors.Is(err, models.BannerNotFound) {
+			return banner, err
+		}
+	}
+	return models.Banner{}, models.BannerNotFound
+}
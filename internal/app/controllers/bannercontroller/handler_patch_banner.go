@@ -2,60 +2,152 @@ package bannercontroller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"project/internal/app/controllers"
 	"project/internal/app/models"
+	"time"
 )
 
 type bannerUpdater interface {
-	UpdateBanner(ctx context.Context, banner models.Banner) (bool, error)
+	UpdateBanner(ctx context.Context, bannerID int, patch models.BannerPatch, ifMatch []int, principal models.Principal) (models.Banner, error)
 }
 
+// patchBannerRequest only changes the fields present in the body. content is
+// a JSON Merge Patch (RFC 7396) of the current content, localized_content
+// patches each variant the same way and a null variant removes the locale.
 type patchBannerRequest struct {
-	FeatureID int            `json:"feature_id,omitempty"`
-	TagIDs    []int          `json:"tag_ids,omitempty"`
-	Content   map[string]any `json:"content,omitempty"`
-	IsActive  bool           `json:"is_active,omitempty"`
+	FeatureID        *int                      `json:"feature_id"`
+	TagIDs           *[]int                    `json:"tag_ids"`
+	Content          map[string]any            `json:"content"`
+	LocalizedContent map[string]map[string]any `json:"localized_content"`
+	IsActive         *bool                     `json:"is_active"`
+	ActiveFrom       optionalTime              `json:"active_from"`
+	ActiveUntil      optionalTime              `json:"active_until"`
+}
+
+// validate checks the identifiers present in the body, the schedule can only
+// be checked against the stored banner.
+func (r *patchBannerRequest) validate() error {
+	var errs []error
+	if r.FeatureID != nil && *r.FeatureID < 0 {
+		errs = append(errs, controllers.NewFieldError(controllers.FieldInvalid, "feature_id", "feature_id must not be negative"))
+	}
+	if r.TagIDs != nil {
+		if len(*r.TagIDs) == 0 {
+			errs = append(errs, controllers.NewFieldError(controllers.FieldRequired, "tag_ids", "tag_ids must list at least one tag"))
+		}
+		for _, tagID := range *r.TagIDs {
+			if tagID <= 0 {
+				errs = append(errs, controllers.NewFieldError(controllers.FieldInvalid, "tag_ids", "tag_ids must be positive"))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// optionalTime tells an absent field from an explicit null.
+type optionalTime struct {
+	Set   bool
+	Value *time.Time
+}
+
+func (o *optionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	o.Value = &t
+	return nil
 }
 
 func (c *controller) PatchHandler() gin.HandlerFunc {
 	const op = "bannercontroller.PatchBannerHandler"
 	return func(ctx *gin.Context) {
-		id, err := controllers.ParseQueryParam(ctx, "id", true, 0, controllers.ConvToInt)
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
 		if err != nil {
 			c.log.Errorf("%s : Failed to parse id: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		ifMatch, err := parseIfMatch(ctx)
+		if respondPrecondition(ctx, err) {
+			return
+		}
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse If-Match: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
 		var req patchBannerRequest
-		if err := ctx.ShouldBind(&req); err != nil {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
 			c.log.Errorf("%s : Failed to parse body: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		if err := req.validate(); err != nil {
+			c.log.Errorf("%s : Invalid patch: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
-		banner := models.Banner{
-			ID:       id,
-			TagIDs:   req.TagIDs,
-			Content:  req.Content,
-			IsActive: req.IsActive,
+		patch := models.BannerPatch{
+			TagIDs:           req.TagIDs,
+			FeatureID:        req.FeatureID,
+			Content:          req.Content,
+			LocalizedContent: req.LocalizedContent,
+			IsActive:         req.IsActive,
+			SetActiveFrom:    req.ActiveFrom.Set,
+			ActiveFrom:       req.ActiveFrom.Value,
+			SetActiveUntil:   req.ActiveUntil.Set,
+			ActiveUntil:      req.ActiveUntil.Value,
 		}
 
-		ok, err := c.bs.UpdateBanner(ctx, banner)
+		principal, err := controllers.GetPrincipal(ctx)
 		if err != nil {
-			c.log.Errorf("%s : Failed to update banner: %s", op, err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": controllers.InternalServerError})
+			c.log.Errorf("%s : Failed to get principal: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		// The feature grants are checked by the service under the row lock,
+		// against both the current and the new feature_id.
+		banner, err := c.bs.UpdateBanner(ctx, id, patch, ifMatch, principal)
+		if respondForbidden(ctx, err) || respondConflict(ctx, err) || respondPrecondition(ctx, err) || respondInvalidContent(ctx, err) {
+			return
+		}
+
+		if errors.Is(err, models.BannerNotFound) {
+			c.log.Errorf("%s : Not found banner: %d", op, id)
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, BannerNotFound)
 			return
 		}
 
-		if !ok {
+		if errors.Is(err, models.InvalidSchedule) {
+			c.log.Errorf("%s : Invalid schedule: %s", op, err)
+			controllers.RespondBadRequest(ctx, scheduleFieldError(err))
+			return
+		}
+
+		if err != nil {
 			c.log.Errorf("%s : Failed to update banner: %s", op, err)
-			ctx.JSON(http.StatusNotFound, gin.H{"error": BannerNotFound})
+			controllers.RespondInternalError(ctx)
 			return
 		}
 
+		ctx.Header("ETag", banner.ETag)
 		ctx.JSON(http.StatusOK, gin.H{"status": controllers.OK})
 	}
 }
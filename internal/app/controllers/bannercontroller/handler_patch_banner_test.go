@@ -0,0 +1,128 @@
+package bannercontroller
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPatchBannerRequest_Presence(t *testing.T) {
+	var req patchBannerRequest
+	body := `{"content": {"title": "new"}, "active_until": null}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.FeatureID != nil || req.TagIDs != nil || req.IsActive != nil {
+		t.Error("expected absent fields to stay nil")
+	}
+
+	if req.ActiveFrom.Set {
+		t.Error("expected absent active_from to be unset")
+	}
+
+	if !req.ActiveUntil.Set || req.ActiveUntil.Value != nil {
+		t.Error("expected null active_until to be set to nil")
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    []int
+		wantErr bool
+	}{
+		{header: "", wantErr: true},
+		{header: "*", want: nil},
+		{header: `"3"`, want: []int{3}},
+		{header: `"3", "5"`, want: []int{3, 5}},
+		{header: `3`, wantErr: true},
+		{header: `W/"3"`, want: []int{}},
+		{header: `W/"3", "5"`, want: []int{5}},
+		{header: `W/3`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodPatch, "/banner/1", nil)
+		if tt.header != "" {
+			ctx.Request.Header.Set("If-Match", tt.header)
+		}
+
+		got, err := parseIfMatch(ctx)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseIfMatch(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			continue
+		}
+		if !slices.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+			t.Errorf("parseIfMatch(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestPatchHandler_InvalidIdentifiers(t *testing.T) {
+	c := newTestController(&fakeBannerService{})
+	admin := models.Principal{Admin: true}
+
+	tests := []struct {
+		body  string
+		field string
+		code  string
+	}{
+		{body: `{"tag_ids": []}`, field: "tag_ids", code: controllers.FieldRequired},
+		{body: `{"tag_ids": [1, 0]}`, field: "tag_ids", code: controllers.FieldInvalid},
+		{body: `{"feature_id": -1}`, field: "feature_id", code: controllers.FieldInvalid},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/banner/1", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", "*")
+		rec := serveRequest(c.PatchHandler(), "/banner/:id", req, admin)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tt.body, rec.Code)
+			continue
+		}
+
+		var res controllers.ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Details) != 1 || res.Details[0].Field != tt.field || res.Details[0].Code != tt.code {
+			t.Errorf("%s: expected %s %s, got %+v", tt.body, tt.field, tt.code, res.Details)
+		}
+	}
+}
+
+func TestPatchHandler_IfMatch(t *testing.T) {
+	c := newTestController(&fakeBannerService{banners: map[int]models.BannerDetails{
+		1: {Banner: models.Banner{ID: 1, Version: 3}},
+	}})
+	admin := models.Principal{Admin: true}
+
+	tests := []struct {
+		ifMatch string
+		want    int
+	}{
+		{ifMatch: `"3"`, want: http.StatusOK},
+		{ifMatch: `W/"3"`, want: http.StatusPreconditionFailed},
+		{ifMatch: `"2"`, want: http.StatusPreconditionFailed},
+		{ifMatch: `W/3`, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/banner/1", strings.NewReader(`{"is_active": true}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", tt.ifMatch)
+		rec := serveRequest(c.PatchHandler(), "/banner/:id", req, admin)
+		if rec.Code != tt.want {
+			t.Errorf("If-Match %s: expected %d, got %d: %s", tt.ifMatch, tt.want, rec.Code, rec.Body)
+		}
+	}
+}
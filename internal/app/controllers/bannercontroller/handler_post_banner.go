@@ -2,21 +2,57 @@ package bannercontroller
 
 import (
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"project/internal/app/controllers"
 	"project/internal/app/models"
+	"time"
 )
 
 type bannerSaver interface {
-	SaveBanner(ctx context.Context, banner models.Banner) (int, error)
+	SaveBanner(ctx context.Context, banner models.Banner, author string) (int, error)
 }
 
+// postBannerRequest is a new banner, feature_id and tag_ids are required.
 type postBannerRequest struct {
-	FeatureID int            `json:"feature_id"`
+	FeatureID *int           `json:"feature_id"`
 	TagIDs    []int          `json:"tag_ids"`
 	Content   map[string]any `json:"content"`
-	IsActive  bool           `json:"is_active"`
+	// LocalizedContent holds content variants by locale.
+	LocalizedContent map[string]map[string]any `json:"localized_content"`
+	IsActive         bool                      `json:"is_active"`
+	ActiveFrom       *time.Time                `json:"active_from"`
+	ActiveUntil      *time.Time                `json:"active_until"`
+}
+
+// validate reports every missing required field and an invalid schedule at
+// once, joined so that each becomes its own field error.
+func (r *postBannerRequest) validate() error {
+	var errs []error
+	if r.FeatureID == nil {
+		errs = append(errs, controllers.NewFieldError(controllers.FieldRequired, "feature_id", "feature_id is required"))
+	}
+	if len(r.TagIDs) == 0 {
+		errs = append(errs, controllers.NewFieldError(controllers.FieldRequired, "tag_ids", "tag_ids must list at least one tag"))
+	}
+	if err := models.ValidateSchedule(r.ActiveFrom, r.ActiveUntil); err != nil {
+		errs = append(errs, scheduleFieldError(err))
+	}
+	return errors.Join(errs...)
+}
+
+// banner is the request as a banner, it must have passed validate.
+func (r *postBannerRequest) banner() models.Banner {
+	return models.Banner{
+		TagIDs:           r.TagIDs,
+		FeatureID:        *r.FeatureID,
+		Content:          r.Content,
+		LocalizedContent: r.LocalizedContent,
+		IsActive:         r.IsActive,
+		ActiveFrom:       r.ActiveFrom,
+		ActiveUntil:      r.ActiveUntil,
+	}
 }
 
 func (c *controller) PostHandler() gin.HandlerFunc {
@@ -25,20 +61,29 @@ func (c *controller) PostHandler() gin.HandlerFunc {
 		var req postBannerRequest
 		if err := ctx.ShouldBind(&req); err != nil {
 			c.log.Errorf("%s : Failed to parse body: %s", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
+			controllers.RespondBadRequest(ctx, err)
 			return
 		}
 
-		id, err := c.bs.SaveBanner(ctx, models.Banner{
-			TagIDs:    req.TagIDs,
-			FeatureID: req.FeatureID,
-			Content:   req.Content,
-			IsActive:  req.IsActive,
-		})
+		if err := req.validate(); err != nil {
+			c.log.Errorf("%s : Invalid banner: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		if !c.authorize(ctx, models.ScopeBannersWrite, *req.FeatureID) {
+			return
+		}
+
+		id, err := c.bs.SaveBanner(ctx, req.banner(), controllers.GetSubject(ctx))
+
+		if respondConflict(ctx, err) || respondInvalidContent(ctx, err) {
+			return
+		}
 
 		if err != nil {
 			c.log.Errorf("%s : Failed to save banner: %s", op, err)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": controllers.InternalServerError})
+			controllers.RespondInternalError(ctx)
 			return
 		}
 
@@ -0,0 +1,41 @@
+package bannercontroller
+
+import (
+	"encoding/json"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+	"testing"
+)
+
+func TestPostHandler_RequiredFields(t *testing.T) {
+	bs := &fakeBannerService{}
+	c := newTestController(bs)
+	admin := models.Principal{Admin: true}
+
+	rec := serve(c.PostHandler(), http.MethodPost, "/banner", "/banner", admin, `{"content": {"title": "some_title"}}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+
+	var res controllers.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Details) != 2 ||
+		res.Details[0].Field != "feature_id" || res.Details[0].Code != controllers.FieldRequired ||
+		res.Details[1].Field != "tag_ids" || res.Details[1].Code != controllers.FieldRequired {
+		t.Errorf("expected feature_id and tag_ids to be reported missing, got %+v", res.Details)
+	}
+	if len(bs.saved) != 0 {
+		t.Error("expected nothing to be saved")
+	}
+
+	rec = serve(c.PostHandler(), http.MethodPost, "/banner", "/banner", admin, `{"feature_id": 0, "tag_ids": [1], "content": {}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for feature 0, got %d: %s", rec.Code, rec.Body)
+	}
+	if len(bs.saved) != 1 || bs.saved[0].FeatureID != 0 {
+		t.Errorf("unexpected saved banners %+v", bs.saved)
+	}
+}
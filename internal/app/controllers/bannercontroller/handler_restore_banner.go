@@ -0,0 +1,50 @@
+package bannercontroller
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+)
+
+type bannerRestorer interface {
+	RestoreBanner(ctx context.Context, bannerID int) (models.Banner, error)
+}
+
+func (c *controller) RestoreHandler() gin.HandlerFunc {
+	const op = "bannercontroller.RestoreHandler"
+	return func(ctx *gin.Context) {
+		id, err := controllers.ParsePathParam(ctx, "id", controllers.ConvToInt)
+		if err != nil {
+			c.log.Errorf("%s : Failed to parse id: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		// Deleted banners cannot be read, so their feature is unknown here.
+		if !c.authorize(ctx, models.ScopeBannersWrite) {
+			return
+		}
+
+		banner, err := c.bs.RestoreBanner(ctx, id)
+		if respondConflict(ctx, err) || respondInvalidContent(ctx, err) {
+			return
+		}
+
+		if errors.Is(err, models.BannerNotFound) {
+			controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, DeletedBannerNotFound)
+			return
+		}
+
+		if err != nil {
+			c.log.Errorf("%s : Failed to restore banner: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.Header("ETag", banner.ETag)
+		ctx.IndentedJSON(http.StatusOK, &banner)
+	}
+}
@@ -1,6 +1,17 @@
 package bannercontroller
 
 const BannerNotFound = "Баннер не найден"
+const VersionNotFound = "Версия баннера не найдена"
+const DeletedBannerNotFound = "Удаленный баннер не найден"
+const JobNotFound = "Задача не найдена"
+const BannerConflict = "Баннер для этого тега и фичи уже существует"
+const ContentInvalid = "Содержимое баннера не соответствует схеме фичи"
+const SchemaNotFound = "Схема фичи не найдена"
+const InvalidSchema = "Некорректная JSON Schema"
+const VersionMismatch = "Баннер был изменен, получите актуальную версию"
+const IfMatchRequired = "Требуется заголовок If-Match"
+const TagForbidden = "Пользователь не принадлежит к этому тегу"
+const FeatureForbidden = "Нет прав на баннеры этой фичи"
 
 const BannerCreated = "Created"
 const BannerDeleted = "Баннер успешно удален"
@@ -0,0 +1,192 @@
+package bannercontroller
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"project/internal/app/models"
+	"strings"
+)
+
+const ifMatchHeader = "If-Match"
+
+var ifMatchMissing = controllers.NewFieldError(controllers.FieldRequired, ifMatchHeader, "If-Match header is required")
+
+// conflictResponse is the 409 body, BannerID is the banner that already uses
+// the (tag_id, feature_id) pair.
+type conflictResponse struct {
+	controllers.ErrorResponse
+	BannerID int `json:"banner_id"`
+}
+
+// respondConflict writes 409 with the id of the clashing banner if err is a
+// *models.BannerConflictError and reports whether it did.
+func respondConflict(ctx *gin.Context, err error) bool {
+	var conflict *models.BannerConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	ctx.AbortWithStatusJSON(http.StatusConflict, &conflictResponse{
+		ErrorResponse: controllers.ErrorResponse{Error: BannerConflict, Code: controllers.CodeConflict},
+		BannerID:      conflict.BannerID,
+	})
+	return true
+}
+
+// respondInvalidContent writes 422 with the failing content paths if err is a
+// *models.ContentValidationError and reports whether it did.
+func respondInvalidContent(ctx *gin.Context, err error) bool {
+	var invalid *models.ContentValidationError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+
+	controllers.RespondError(ctx, http.StatusUnprocessableEntity, controllers.CodeInvalidContent, ContentInvalid, contentFieldErrors(invalid)...)
+	return true
+}
+
+// contentFieldErrors names the failing content paths as body fields, the
+// content root itself is just "content" and a variant root
+// "localized_content/<locale>".
+func contentFieldErrors(err *models.ContentValidationError) []controllers.FieldError {
+	res := make([]controllers.FieldError, len(err.Errors))
+	for i, e := range err.Errors {
+		field := "content"
+		if e.Locale != "" {
+			field = "localized_content/" + e.Locale
+		}
+		res[i] = controllers.FieldError{
+			Code:    controllers.FieldSchema,
+			Field:   field + strings.TrimSuffix(e.Path, "/"),
+			Message: e.Message,
+		}
+	}
+	return res
+}
+
+// scheduleFieldError points models.InvalidSchedule at the active_until field.
+func scheduleFieldError(err error) error {
+	if errors.Is(err, models.InvalidSchedule) {
+		return controllers.NewFieldError(controllers.FieldInvalid, "active_until", err.Error())
+	}
+	return err
+}
+
+// authorize writes 403 and reports false unless the caller holds scope for
+// every one of the features, without features only an unscoped grant will do.
+func (c *controller) authorize(ctx *gin.Context, scope string, featureIDs ...int) bool {
+	const op = "bannercontroller.authorize"
+	principal, err := controllers.GetPrincipal(ctx)
+	if err != nil {
+		c.log.Errorf("%s : Failed to get principal: %s", op, err)
+		controllers.RespondInternalError(ctx)
+		return false
+	}
+
+	if len(featureIDs) == 0 && !principal.HasScope(scope) {
+		controllers.RespondError(ctx, http.StatusForbidden, controllers.CodeForbidden, FeatureForbidden,
+			*controllers.NewFieldError(controllers.FieldRequired, "scope", scope+" scope is required for all features"))
+		return false
+	}
+	for _, featureID := range featureIDs {
+		if !principal.HasFeatureScope(scope, featureID) {
+			respondForbidden(ctx, &models.FeatureScopeError{Scope: scope, FeatureID: featureID})
+			return false
+		}
+	}
+	return true
+}
+
+// authorizeBanner is authorize for the feature of an existing banner, the
+// banner is only read for feature-scoped callers. It writes 404 both for a
+// missing banner and for one the caller may not read, so ids in other
+// features cannot be probed.
+func (c *controller) authorizeBanner(ctx *gin.Context, scope string, bannerID int) bool {
+	const op = "bannercontroller.authorizeBanner"
+	principal, err := controllers.GetPrincipal(ctx)
+	if err != nil {
+		c.log.Errorf("%s : Failed to get principal: %s", op, err)
+		controllers.RespondInternalError(ctx)
+		return false
+	}
+	if principal.HasScope(scope) && principal.HasScope(models.ScopeBannersRead) {
+		return true
+	}
+
+	banner, err := c.bs.GetBanner(ctx, bannerID)
+	if err == nil {
+		err = principal.AuthorizeBanner(scope, banner.Banner)
+	}
+	if errors.Is(err, models.BannerNotFound) {
+		controllers.RespondError(ctx, http.StatusNotFound, controllers.CodeNotFound, BannerNotFound)
+		return false
+	}
+	if respondForbidden(ctx, err) {
+		return false
+	}
+	if err != nil {
+		c.log.Errorf("%s : Failed to get banner %d: %s", op, bannerID, err)
+		controllers.RespondInternalError(ctx)
+		return false
+	}
+
+	return true
+}
+
+// respondForbidden writes 403 naming the feature if err is a
+// *models.FeatureScopeError and reports whether it did.
+func respondForbidden(ctx *gin.Context, err error) bool {
+	var forbidden *models.FeatureScopeError
+	if !errors.As(err, &forbidden) {
+		return false
+	}
+
+	controllers.RespondError(ctx, http.StatusForbidden, controllers.CodeForbidden, FeatureForbidden,
+		*controllers.NewFieldError(controllers.FieldInvalid, "feature_id", forbidden.Error()))
+	return true
+}
+
+// parseIfMatch returns the banner versions listed in the If-Match header, nil
+// for "*". A missing header is an error, edits must be conditional. If-Match
+// compares strongly, so weak ETags are valid but never match a version.
+func parseIfMatch(ctx *gin.Context) ([]int, error) {
+	header := strings.TrimSpace(ctx.GetHeader(ifMatchHeader))
+	if header == "" {
+		return nil, ifMatchMissing
+	}
+	if header == "*" {
+		return nil, nil
+	}
+
+	versions := []int{}
+	for _, etag := range strings.Split(header, ",") {
+		etag, isWeak := strings.CutPrefix(strings.TrimSpace(etag), "W/")
+		version, err := models.ParseETag(etag)
+		if err != nil {
+			return nil, controllers.NewFieldError(controllers.FieldInvalid, ifMatchHeader, "If-Match must list quoted banner ETags or be *")
+		}
+		if !isWeak {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, nil
+}
+
+// respondPrecondition writes 428 for a missing If-Match header and 412 if err
+// is models.VersionMismatch, it reports whether it wrote a response.
+func respondPrecondition(ctx *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ifMatchMissing):
+		controllers.RespondError(ctx, http.StatusPreconditionRequired, controllers.CodePreconditionRequired, IfMatchRequired, *ifMatchMissing)
+	case errors.Is(err, models.VersionMismatch):
+		controllers.RespondError(ctx, http.StatusPreconditionFailed, controllers.CodePreconditionFailed, VersionMismatch)
+	default:
+		return false
+	}
+	return true
+}
@@ -1,17 +1,20 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"net/http/httptest"
+	"slices"
 	"strconv"
 	"testing"
 )
 
 func TestController_ParseParam(t *testing.T) {
-	params := gin.Params{
-		{Key: "tag_id", Value: "12"},
-	}
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/?tag_id=12", nil)
 
-	tagID, err := ParseQueryParam(params, "tag_id", true, func(param string) (int, error) {
+	tagID, err := ParseQueryParam(ctx, "tag_id", true, -1, func(param string) (int, error) {
 		return strconv.Atoi(param)
 	})
 
@@ -19,7 +22,7 @@ func TestController_ParseParam(t *testing.T) {
 		t.Error(err)
 	}
 
-	notRequiredBool, err := ParseQueryParam(params, "use", false, ConvToBool)
+	notRequiredBool, err := ParseQueryParam(ctx, "use", false, false, ConvToBool)
 	if err != nil {
 		t.Error(err)
 	}
@@ -27,3 +30,74 @@ func TestController_ParseParam(t *testing.T) {
 	t.Log(tagID)
 	t.Log(notRequiredBool)
 }
+
+func TestController_ParsePathParam(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Params = gin.Params{
+		{Key: "id", Value: "7"},
+	}
+
+	id, err := ParsePathParam(ctx, "id", ConvToInt)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if id != 7 {
+		t.Errorf("expected id 7, got %d", id)
+	}
+
+	if _, err := ParsePathParam(ctx, "version", ConvToInt); err == nil {
+		t.Error("expected error for missing param")
+	}
+}
+
+func TestController_FieldErrors(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/?limit=many", nil)
+
+	_, tagErr := ParseQueryParam(ctx, "tag_id", true, -1, ConvToInt)
+	_, limitErr := ParseQueryParam(ctx, "limit", false, 10, ConvToInt)
+
+	fields := FieldErrors(errors.Join(tagErr, limitErr))
+	if len(fields) != 2 {
+		t.Fatalf("expected 2 field errors, got %v", fields)
+	}
+
+	if fields[0].Field != "tag_id" || fields[0].Code != FieldRequired {
+		t.Errorf("unexpected tag_id error: %+v", fields[0])
+	}
+
+	if fields[1].Field != "limit" || fields[1].Code != FieldInvalid {
+		t.Errorf("unexpected limit error: %+v", fields[1])
+	}
+
+	var body struct {
+		FeatureID int `json:"feature_id"`
+	}
+	err := json.Unmarshal([]byte(`{"feature_id": "one"}`), &body)
+	if fields := FieldErrors(err); len(fields) != 1 || fields[0].Field != "feature_id" {
+		t.Errorf("expected feature_id type error, got %v", fields)
+	}
+
+	if fields := FieldErrors(errors.New("unexpected")); len(fields) != 1 || fields[0].Field != "request" || fields[0].Code != FieldUnknown {
+		t.Errorf("expected an unknown error on the request, got %v", fields)
+	}
+}
+
+func TestController_PreferredLocales(t *testing.T) {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/", nil)
+	ctx.Request.Header.Set("Accept-Language", "fr;q=0.5, en-US, *;q=0.1, de;q=0, ru;q=0.8")
+
+	locales := PreferredLocales(ctx)
+	if !slices.Equal(locales, []string{"en-US", "ru", "fr"}) {
+		t.Errorf("unexpected locales %v", locales)
+	}
+
+	ctx, _ = gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/?lang=kk", nil)
+	ctx.Request.Header.Set("Accept-Language", "en")
+	if locales := PreferredLocales(ctx); !slices.Equal(locales, []string{"kk"}) {
+		t.Errorf("expected lang to win over Accept-Language, got %v", locales)
+	}
+}
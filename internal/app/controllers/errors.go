@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// Error codes, the ErrorResponse.Code values.
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodeInvalidContent       = "invalid_content"
+	CodeInternal             = "internal_error"
+)
+
+// Field error codes, the FieldError.Code values.
+const (
+	FieldRequired   = "required"
+	FieldInvalid    = "invalid"
+	FieldOutOfRange = "out_of_range"
+	FieldMalformed  = "malformed"
+	FieldSchema     = "schema"
+	// FieldUnknown marks a failure that cannot be tied to a single field,
+	// Field is "request" then.
+	FieldUnknown = "unknown"
+)
+
+// FieldError is what is wrong with a single parameter or body field.
+type FieldError struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func NewFieldError(code string, field string, message string) *FieldError {
+	return &FieldError{Code: code, Field: field, Message: message}
+}
+
+// ErrorResponse is the body of every error response. Error is the human
+// readable message, Code and Details are meant for machines.
+type ErrorResponse struct {
+	Error   string       `json:"error"`
+	Code    string       `json:"code"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+func RespondError(ctx *gin.Context, status int, code string, message string, details ...FieldError) {
+	ctx.AbortWithStatusJSON(status, &ErrorResponse{Error: message, Code: code, Details: details})
+}
+
+// RespondBadRequest writes 400 with a FieldError for every failure in err,
+// see FieldErrors.
+func RespondBadRequest(ctx *gin.Context, err error) {
+	RespondError(ctx, http.StatusBadRequest, CodeInvalidRequest, BadRequest, FieldErrors(err)...)
+}
+
+func RespondInternalError(ctx *gin.Context) {
+	RespondError(ctx, http.StatusInternalServerError, CodeInternal, InternalServerError)
+}
+
+// FieldErrors turns err into field errors. errors.Join-ed errors give one
+// field error each, *FieldError is kept as is and JSON decoding errors point
+// at the body field that failed. Anything else is a FieldUnknown error on the
+// request as a whole.
+func FieldErrors(err error) []FieldError {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var res []FieldError
+		for _, e := range joined.Unwrap() {
+			res = append(res, FieldErrors(e)...)
+		}
+		return res
+	}
+
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return []FieldError{*fieldErr}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Code:    FieldInvalid,
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("%s must be %s", typeErr.Field, typeErr.Type),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return []FieldError{{Code: FieldMalformed, Field: "body", Message: "body is not valid JSON"}}
+	}
+
+	return []FieldError{{Code: FieldUnknown, Field: "request", Message: err.Error()}}
+}
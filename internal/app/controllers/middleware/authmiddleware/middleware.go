@@ -5,11 +5,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"project/internal/app/controllers"
+	"project/internal/app/models"
 	"project/internal/logger"
+	"strings"
 )
 
+const apiKeyScheme = "ApiKey "
+
 type authService interface {
-	Authenticate(ctx context.Context, token string) (bool, error)
+	Authenticate(ctx context.Context, token string) (models.Principal, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (models.Principal, error)
 }
 
 type middleware struct {
@@ -24,46 +29,93 @@ func New(log logger.Logger, as authService) *middleware {
 	}
 }
 
+// Auth authenticates the caller by an "Authorization: ApiKey <key>" header
+// if there is one and by the JWT in the token header otherwise.
 func (m *middleware) Auth() gin.HandlerFunc {
 	const op = "authmiddleware.Auth"
 	return func(ctx *gin.Context) {
 		m.log.Info("Authorizing")
 
+		if apiKey, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), apiKeyScheme); ok {
+			principal, err := m.as.AuthenticateAPIKey(ctx, strings.TrimSpace(apiKey))
+			if err != nil {
+				m.log.Errorf("%s Failed to authenticate api key: %v", op, err)
+				controllers.RespondError(ctx, http.StatusUnauthorized, controllers.CodeUnauthorized, Unauthorized,
+					*controllers.NewFieldError(controllers.FieldInvalid, "Authorization", "api key is invalid, expired or revoked"))
+				return
+			}
+
+			setPrincipal(ctx, principal)
+			ctx.Next()
+			return
+		}
+
 		tokenString := ctx.Request.Header.Get("token")
 		if tokenString == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": Unauthorized})
-			ctx.Abort()
+			controllers.RespondError(ctx, http.StatusUnauthorized, controllers.CodeUnauthorized, Unauthorized,
+				*controllers.NewFieldError(controllers.FieldRequired, "token", "token header is required"))
 			return
 		}
 
-		admin, err := m.as.Authenticate(ctx, tokenString)
+		principal, err := m.as.Authenticate(ctx, tokenString)
 		if err != nil {
 			m.log.Errorf("%s Failed to authenticate: %v", op, err)
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": controllers.BadRequest})
-			ctx.Abort()
+			controllers.RespondError(ctx, http.StatusUnauthorized, controllers.CodeUnauthorized, Unauthorized,
+				*controllers.NewFieldError(controllers.FieldInvalid, "token", "token is invalid or expired"))
 			return
 		}
 
-		ctx.Set("admin", admin)
+		setPrincipal(ctx, principal)
 		ctx.Next()
 	}
 }
 
+func setPrincipal(ctx *gin.Context, principal models.Principal) {
+	ctx.Set("principal", principal)
+	ctx.Set("admin", principal.Admin)
+	ctx.Set("subject", principal.Subject)
+}
+
 func (m *middleware) AdminRequired() gin.HandlerFunc {
 	const op = "middleware.AdminRequired"
 	return func(ctx *gin.Context) {
 		admin, err := controllers.CheckAdminStatus(ctx)
 		if err != nil {
 			m.log.Errorf("%s Failed to check admin status: %v", op, err)
-			ctx.Abort()
+			controllers.RespondInternalError(ctx)
+			return
 		}
 
 		if !admin {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": Forbidden})
-			ctx.Abort()
+			controllers.RespondError(ctx, http.StatusForbidden, controllers.CodeForbidden, Forbidden)
 			return
 		}
 
 		ctx.Next()
 	}
 }
+
+// RequireScope lets the request through if the caller holds every scope, at
+// least for some feature. Handlers check feature-scoped grants against the
+// banners they touch.
+func (m *middleware) RequireScope(scopes ...string) gin.HandlerFunc {
+	const op = "middleware.RequireScope"
+	return func(ctx *gin.Context) {
+		principal, err := controllers.GetPrincipal(ctx)
+		if err != nil {
+			m.log.Errorf("%s Failed to get principal: %v", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		for _, scope := range scopes {
+			if !principal.HasAnyScope(scope) {
+				controllers.RespondError(ctx, http.StatusForbidden, controllers.CodeForbidden, Forbidden,
+					*controllers.NewFieldError(controllers.FieldRequired, "scope", scope+" scope is required"))
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
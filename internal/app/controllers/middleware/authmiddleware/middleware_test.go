@@ -0,0 +1,50 @@
+package authmiddleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"project/internal/app/models"
+	"project/internal/logger"
+	"testing"
+)
+
+func TestMiddleware_RequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := New(logger.New(), nil)
+
+	tests := []struct {
+		name      string
+		principal models.Principal
+		want      int
+	}{
+		{name: "unscoped", principal: models.Principal{Scopes: []string{models.ScopeBannersWrite}}, want: http.StatusOK},
+		{name: "feature scoped", principal: models.Principal{Scopes: []string{models.FeatureScope(models.ScopeBannersWrite, 7)}}, want: http.StatusOK},
+		{name: "admin", principal: models.Principal{Admin: true}, want: http.StatusOK},
+		{name: "other scope", principal: models.Principal{Scopes: []string{models.ScopeBannersRead}}, want: http.StatusForbidden},
+		{name: "no scopes", principal: models.Principal{}, want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		router := gin.New()
+		router.GET("/", func(ctx *gin.Context) {
+			setPrincipal(ctx, tt.principal)
+		}, m.RequireScope(models.ScopeBannersWrite), func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, rec.Code)
+		}
+	}
+
+	router := gin.New()
+	router.GET("/", m.RequireScope(models.ScopeBannersRead))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 without a principal, got %d", rec.Code)
+	}
+}
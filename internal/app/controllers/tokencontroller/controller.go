@@ -0,0 +1,22 @@
+package tokencontroller
+
+import (
+	"project/internal/logger"
+)
+
+type tokenService interface {
+	tokenRevoker
+	subjectRevoker
+}
+
+type controller struct {
+	log logger.Logger
+	as  tokenService
+}
+
+func New(log logger.Logger, as tokenService) *controller {
+	return &controller{
+		log: log,
+		as:  as,
+	}
+}
@@ -0,0 +1,43 @@
+package tokencontroller
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+)
+
+type subjectRevoker interface {
+	RevokeSubject(ctx context.Context, subject string) error
+}
+
+type revokeSubjectRequest struct {
+	Subject string `json:"subject"`
+}
+
+// RevokeSubjectHandler blocks every token issued to the subject so far, the
+// user has to obtain a new one.
+func (c *controller) RevokeSubjectHandler() gin.HandlerFunc {
+	const op = "tokencontroller.RevokeSubjectHandler"
+	return func(ctx *gin.Context) {
+		var req revokeSubjectRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			c.log.Errorf("%s : Failed to parse body: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		if req.Subject == "" {
+			controllers.RespondBadRequest(ctx, controllers.NewFieldError(controllers.FieldRequired, "subject", "subject is required"))
+			return
+		}
+
+		if err := c.as.RevokeSubject(ctx, req.Subject); err != nil {
+			c.log.Errorf("%s : Failed to revoke tokens of subject: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,45 @@
+package tokencontroller
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/internal/app/controllers"
+	"time"
+)
+
+type tokenRevoker interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt *time.Time) error
+}
+
+type revokeTokenRequest struct {
+	JTI string `json:"jti"`
+	// ExpiresAt is the exp of the token, the revocation is kept until then.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// RevokeTokenHandler blocks a single token by its jti.
+func (c *controller) RevokeTokenHandler() gin.HandlerFunc {
+	const op = "tokencontroller.RevokeTokenHandler"
+	return func(ctx *gin.Context) {
+		var req revokeTokenRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			c.log.Errorf("%s : Failed to parse body: %s", op, err)
+			controllers.RespondBadRequest(ctx, err)
+			return
+		}
+
+		if req.JTI == "" {
+			controllers.RespondBadRequest(ctx, controllers.NewFieldError(controllers.FieldRequired, "jti", "jti is required"))
+			return
+		}
+
+		if err := c.as.RevokeToken(ctx, req.JTI, req.ExpiresAt); err != nil {
+			c.log.Errorf("%s : Failed to revoke token: %s", op, err)
+			controllers.RespondInternalError(ctx)
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+	}
+}
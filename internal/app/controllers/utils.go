@@ -3,7 +3,11 @@ package controllers
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"project/internal/app/models"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func ConvToInt(param string) (int, error) {
@@ -14,11 +18,15 @@ func ConvToBool(param string) (bool, error) {
 	return strconv.ParseBool(param)
 }
 
+func ConvToTime(param string) (time.Time, error) {
+	return time.Parse(time.RFC3339, param)
+}
+
 func ParseQueryParam[T any](queryContext *gin.Context, name string, required bool, defaultVal T, convFunc func(param string) (T, error)) (convertedParam T, err error) {
 	param := queryContext.Query(name)
 	if param == "" {
 		if required {
-			return convertedParam, errors.New(name + " is required")
+			return convertedParam, NewFieldError(FieldRequired, name, name+" is required")
 		}
 
 		convertedParam = defaultVal
@@ -27,12 +35,97 @@ func ParseQueryParam[T any](queryContext *gin.Context, name string, required boo
 
 	convertedParam, err = convFunc(param)
 	if err != nil {
-		return convertedParam, errors.New(name + " is invalid")
+		return convertedParam, NewFieldError(FieldInvalid, name, name+" is invalid")
 	}
 
 	return convertedParam, nil
 }
 
+// ParseOptionalQueryParam is ParseQueryParam for parameters without a default, nil means the parameter is absent.
+func ParseOptionalQueryParam[T any](queryContext *gin.Context, name string, convFunc func(param string) (T, error)) (*T, error) {
+	param := queryContext.Query(name)
+	if param == "" {
+		return nil, nil
+	}
+
+	convertedParam, err := convFunc(param)
+	if err != nil {
+		return nil, NewFieldError(FieldInvalid, name, name+" is invalid")
+	}
+
+	return &convertedParam, nil
+}
+
+func ParsePathParam[T any](pathContext *gin.Context, name string, convFunc func(param string) (T, error)) (convertedParam T, err error) {
+	param := pathContext.Param(name)
+	if param == "" {
+		return convertedParam, NewFieldError(FieldRequired, name, name+" is required")
+	}
+
+	convertedParam, err = convFunc(param)
+	if err != nil {
+		return convertedParam, NewFieldError(FieldInvalid, name, name+" is invalid")
+	}
+
+	return convertedParam, nil
+}
+
+// PreferredLocales returns the locales the client asked for, most preferred
+// first: the lang query parameter if it is set and Accept-Language otherwise.
+func PreferredLocales(ctx *gin.Context) []string {
+	if lang := strings.TrimSpace(ctx.Query("lang")); lang != "" {
+		return []string{lang}
+	}
+	return ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+}
+
+// ParseAcceptLanguage orders the language ranges of an Accept-Language header
+// by quality. Ranges with q=0, malformed ones and "*" are left out.
+func ParseAcceptLanguage(header string) []string {
+	type languageRange struct {
+		tag string
+		q   float64
+	}
+
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, languageRange{tag: tag, q: q})
+	}
+
+	slices.SortStableFunc(ranges, func(a, b languageRange) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	locales := make([]string, len(ranges))
+	for i, r := range ranges {
+		locales[i] = r.tag
+	}
+	return locales
+}
+
 func CheckAdminStatus(ctx *gin.Context) (isAdmin bool, err error) {
 	admin, ok := ctx.Get("admin")
 	if !ok {
@@ -41,3 +134,17 @@ func CheckAdminStatus(ctx *gin.Context) (isAdmin bool, err error) {
 
 	return admin.(bool), nil
 }
+
+// GetPrincipal returns the caller set by the auth middleware.
+func GetPrincipal(ctx *gin.Context) (models.Principal, error) {
+	principal, ok := ctx.Get("principal")
+	if !ok {
+		return models.Principal{}, errors.New("'principal' is not specified in context")
+	}
+
+	return principal.(models.Principal), nil
+}
+
+func GetSubject(ctx *gin.Context) string {
+	return ctx.GetString("subject")
+}
@@ -2,19 +2,51 @@ package cache
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/redis/go-redis/v9"
 	"project/internal/app/models"
 	"project/internal/logger"
-	"strconv"
 	"time"
 )
 
+const invalidationChannel = "banners:invalidate"
+
+// invalidateScript deletes every locale listed in the locale sets along with
+// the sets and bumps the generation of each pair. KEYS holds the locale sets
+// followed by the generation keys, ARGV the banner key prefix of each set. It
+// runs atomically so no locale written meanwhile is left behind.
+var invalidateScript = redis.NewScript(`
+local n = #ARGV
+for i = 1, n do
+	for _, locale in ipairs(redis.call("SMEMBERS", KEYS[i])) do
+		redis.call("DEL", ARGV[i] .. locale)
+	end
+	redis.call("DEL", KEYS[i])
+	redis.call("INCR", KEYS[n + i])
+end
+return 0
+`)
+
+// setScript stores a banner only if the generation of its pair is still the
+// one read before the banner was loaded. KEYS are the generation key, the
+// banner key and the locale set, ARGV the generation, the banner, the ttl in
+// milliseconds and the locale.
+var setScript = redis.NewScript(`
+if tonumber(redis.call("GET", KEYS[1]) or "0") ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+redis.call("SADD", KEYS[3], ARGV[4])
+redis.call("PEXPIRE", KEYS[3], ARGV[3])
+return 1
+`)
+
 type cache struct {
 	conn *redis.Client
 	log  logger.Logger
+	ttl  time.Duration
 }
 
 func New(logger logger.Logger) (*cache, error) {
@@ -31,47 +63,151 @@ func New(logger logger.Logger) (*cache, error) {
 	return &cache{
 		conn: conn,
 		log:  logger,
+		ttl:  cfg.ttl,
 	}, nil
 }
 
-func (c *cache) SetBanner(ctx context.Context, tagID int, featureID int, banner models.Banner) error {
+func (c *cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Generation returns the number of invalidations of the (tag_id, feature_id)
+// pair. It is read before loading a banner and handed to SetBanner.
+func (c *cache) Generation(ctx context.Context, tagID int, featureID int) (uint64, error) {
+	const op = "cache.Generation"
+	generation, err := c.conn.Get(ctx, generationKey(tagID, featureID)).Uint64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		c.log.Errorf("%s Failed to get generation: %s", op, err)
+		return 0, err
+	}
+
+	return generation, nil
+}
+
+// SetBanner stores the banner localized to locale. Every locale has its own
+// key and expiry, the locales of a (tag_id, feature_id) pair are tracked in a
+// set so they are invalidated together. If the pair was invalidated since
+// generation was read, on any instance, the banner may predate the write
+// behind it and models.StaleBanner is returned instead.
+func (c *cache) SetBanner(ctx context.Context, tagID int, featureID int, locale string, banner models.Banner, generation uint64) error {
 	const op = "cache.SetBanner"
-	h := hash(tagID, featureID)
-	err := c.conn.Set(ctx, h, banner, time.Minute*5).Err()
+	keys := []string{generationKey(tagID, featureID), bannerKey(tagID, featureID, locale), localesKey(tagID, featureID)}
+	// The set outlives every locale key it lists, each write extends it.
+	stored, err := setScript.Run(ctx, c.conn, keys, generation, banner, c.ttl.Milliseconds(), locale).Int()
 	if err != nil {
 		c.log.Errorf("%s Failed to set banner: %s", op, err)
 		return err
 	}
+	if stored == 0 {
+		return models.StaleBanner
+	}
 
 	return nil
 }
 
-func (c *cache) GetBanner(ctx context.Context, tagID int, featureID int) (models.Banner, error) {
+// GetBanner returns the banner cached for locale together with the time left
+// until it expires.
+func (c *cache) GetBanner(ctx context.Context, tagID int, featureID int, locale string) (models.Banner, time.Duration, error) {
 	const op = "cache.GetBanner"
 
-	h := hash(tagID, featureID)
+	key := bannerKey(tagID, featureID, locale)
 
 	var banner models.Banner
-	err := c.conn.Get(ctx, h).Scan(&banner)
+	pipe := c.conn.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	_, err := pipe.Exec(ctx)
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return banner, models.BannerNotFound
+			return banner, 0, models.BannerNotFound
 		}
 
 		c.log.Errorf("%s Failed to get banner from cache: %s", op, err)
-		return banner, err
+		return banner, 0, err
 	}
 
-	return banner, nil
+	if err := get.Scan(&banner); err != nil {
+		c.log.Errorf("%s Failed to decode banner from cache: %s", op, err)
+		return banner, 0, err
+	}
+
+	return banner, ttl.Val(), nil
 }
 
-func hash(args ...int) string {
-	var s string
-	for _, arg := range args {
-		s += strconv.Itoa(arg)
+// InvalidateBanners drops the keys from Redis and notifies every instance
+// subscribed to invalidations.
+func (c *cache) InvalidateBanners(ctx context.Context, keys []models.BannerKey) error {
+	const op = "cache.InvalidateBanners"
+	if len(keys) == 0 {
+		return nil
+	}
+
+	indexes := make([]string, 2*len(keys))
+	prefixes := make([]any, len(keys))
+	for i, key := range keys {
+		indexes[i] = localesKey(key.TagID, key.FeatureID)
+		indexes[len(keys)+i] = generationKey(key.TagID, key.FeatureID)
+		prefixes[i] = bannerKey(key.TagID, key.FeatureID, "")
+	}
+
+	if err := invalidateScript.Run(ctx, c.conn, indexes, prefixes...).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		c.log.Errorf("%s Failed to delete banners: %s", op, err)
+		return err
 	}
-	h := md5.Sum([]byte(s))
-	hashHex := hex.EncodeToString(h[:])
 
-	return hashHex
+	payload, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	if err := c.conn.Publish(ctx, invalidationChannel, payload).Err(); err != nil {
+		c.log.Errorf("%s Failed to publish invalidation: %s", op, err)
+		return err
+	}
+
+	return nil
+}
+
+// SubscribeInvalidations calls handle for every invalidation published by any
+// instance until ctx is cancelled.
+func (c *cache) SubscribeInvalidations(ctx context.Context, handle func(keys []models.BannerKey)) {
+	const op = "cache.SubscribeInvalidations"
+
+	sub := c.conn.Subscribe(ctx, invalidationChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			var keys []models.BannerKey
+			if err := json.Unmarshal([]byte(msg.Payload), &keys); err != nil {
+				c.log.Errorf("%s Failed to decode invalidation: %s", op, err)
+				continue
+			}
+
+			handle(keys)
+		}
+	}
+}
+
+func bannerKey(tagID int, featureID int, locale string) string {
+	return fmt.Sprintf("banner:%d:%d:%s", tagID, featureID, locale)
+}
+
+// localesKey names the set of locales cached for the pair.
+func localesKey(tagID int, featureID int) string {
+	return fmt.Sprintf("banner-locales:%d:%d", tagID, featureID)
+}
+
+// generationKey names the invalidation counter of the pair. It never expires,
+// so it cannot fall back to a value an in-flight load has already read.
+func generationKey(tagID int, featureID int) string {
+	return fmt.Sprintf("banner-generation:%d:%d", tagID, featureID)
 }
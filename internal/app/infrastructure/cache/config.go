@@ -4,12 +4,16 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"time"
 )
 
+const defaultTTL = 5 * time.Minute
+
 type cacheConfig struct {
 	port string
 	host string
 	db   int
+	ttl  time.Duration
 }
 
 func loadConfig() (*cacheConfig, error) {
@@ -33,9 +37,18 @@ func loadConfig() (*cacheConfig, error) {
 		return nil, errors.New("REDIS_DB environment variable not valid")
 	}
 
+	ttl := defaultTTL
+	if t := os.Getenv("CACHE_TTL"); t != "" {
+		ttl, err = time.ParseDuration(t)
+		if err != nil || ttl <= 0 {
+			return nil, errors.New("CACHE_TTL environment variable not valid")
+		}
+	}
+
 	return &cacheConfig{
 		port: port,
 		host: host,
 		db:   dbInt,
+		ttl:  ttl,
 	}, nil
 }
@@ -0,0 +1,271 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"os"
+	"project/internal/app/models"
+	"project/internal/logger"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultMemorySize    = 1024
+	defaultMemoryTTL     = 30 * time.Second
+	defaultStatsInterval = time.Minute
+)
+
+type remoteCache interface {
+	Generation(ctx context.Context, tagID int, featureID int) (uint64, error)
+	SetBanner(ctx context.Context, tagID int, featureID int, locale string, banner models.Banner, generation uint64) error
+	GetBanner(ctx context.Context, tagID int, featureID int, locale string) (models.Banner, time.Duration, error)
+	InvalidateBanners(ctx context.Context, keys []models.BannerKey) error
+	SubscribeInvalidations(ctx context.Context, handle func(keys []models.BannerKey))
+	TTL() time.Duration
+}
+
+type memoryKey struct {
+	tagID     int
+	featureID int
+	locale    string
+}
+
+type memoryEntry struct {
+	key    memoryKey
+	banner models.Banner
+	// expiresAt mirrors the expiry of the remote entry, localExpiresAt bounds
+	// how long this instance may serve the entry without asking the remote.
+	expiresAt      time.Time
+	localExpiresAt time.Time
+}
+
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// memoryCache is a size-bounded LRU in front of the remote cache.
+type memoryCache struct {
+	next remoteCache
+	log  logger.Logger
+	size int
+	ttl  time.Duration
+	// statsInterval is how often RunStatsLogger logs Stats.
+	statsInterval time.Duration
+
+	mu      sync.Mutex
+	entries map[memoryKey]*list.Element
+	order   *list.List
+	// purges counts purges, an entry read from the remote is only kept if no
+	// purge ran while it was being read.
+	purges uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewMemory(log logger.Logger, next remoteCache) (*memoryCache, error) {
+	size := defaultMemorySize
+	if s := os.Getenv("MEMORY_CACHE_SIZE"); s != "" {
+		sizeInt, err := strconv.Atoi(s)
+		if err != nil || sizeInt < 1 {
+			return nil, errors.New("MEMORY_CACHE_SIZE environment variable not valid")
+		}
+		size = sizeInt
+	}
+
+	ttl := defaultMemoryTTL
+	if t := os.Getenv("MEMORY_CACHE_TTL"); t != "" {
+		var err error
+		ttl, err = time.ParseDuration(t)
+		if err != nil || ttl <= 0 {
+			return nil, errors.New("MEMORY_CACHE_TTL environment variable not valid")
+		}
+	}
+
+	statsInterval := defaultStatsInterval
+	if i := os.Getenv("MEMORY_CACHE_STATS_INTERVAL"); i != "" {
+		var err error
+		statsInterval, err = time.ParseDuration(i)
+		if err != nil || statsInterval <= 0 {
+			return nil, errors.New("MEMORY_CACHE_STATS_INTERVAL environment variable not valid")
+		}
+	}
+
+	m := newMemoryCache(log, next, size, ttl)
+	m.statsInterval = statsInterval
+	return m, nil
+}
+
+func newMemoryCache(log logger.Logger, next remoteCache, size int, ttl time.Duration) *memoryCache {
+	return &memoryCache{
+		next:          next,
+		log:           log,
+		size:          size,
+		ttl:           ttl,
+		statsInterval: defaultStatsInterval,
+		entries:       make(map[memoryKey]*list.Element),
+		order:         list.New(),
+	}
+}
+
+func (m *memoryCache) Generation(ctx context.Context, tagID int, featureID int) (uint64, error) {
+	return m.next.Generation(ctx, tagID, featureID)
+}
+
+func (m *memoryCache) SetBanner(ctx context.Context, tagID int, featureID int, locale string, banner models.Banner, generation uint64) error {
+	purges := m.purgeCount()
+	if err := m.next.SetBanner(ctx, tagID, featureID, locale, banner, generation); err != nil {
+		return err
+	}
+
+	m.put(memoryKey{tagID: tagID, featureID: featureID, locale: locale}, banner, m.next.TTL(), purges)
+	return nil
+}
+
+func (m *memoryCache) GetBanner(ctx context.Context, tagID int, featureID int, locale string) (models.Banner, time.Duration, error) {
+	key := memoryKey{tagID: tagID, featureID: featureID, locale: locale}
+	if banner, ttl, ok := m.get(key); ok {
+		m.hits.Add(1)
+		return banner, ttl, nil
+	}
+	m.misses.Add(1)
+
+	purges := m.purgeCount()
+	banner, ttl, err := m.next.GetBanner(ctx, tagID, featureID, locale)
+	if err != nil {
+		return banner, ttl, err
+	}
+
+	m.put(key, banner, ttl, purges)
+	return banner, ttl, nil
+}
+
+func (m *memoryCache) InvalidateBanners(ctx context.Context, keys []models.BannerKey) error {
+	m.purge(keys)
+	return m.next.InvalidateBanners(ctx, keys)
+}
+
+// TTL is the lifetime of the remote entries, the remaining ttl GetBanner
+// reports is always taken from them.
+func (m *memoryCache) TTL() time.Duration {
+	return m.next.TTL()
+}
+
+// RunStatsLogger logs Stats every stats interval until ctx is done.
+func (m *memoryCache) RunStatsLogger(ctx context.Context) {
+	const op = "cache.RunStatsLogger"
+	ticker := time.NewTicker(m.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats := m.Stats()
+		m.log.Infof("%s Memory cache hits %d, misses %d, size %d of %d", op, stats.Hits, stats.Misses, stats.Size, m.size)
+	}
+}
+
+// Listen purges entries invalidated by other instances until ctx is cancelled.
+func (m *memoryCache) Listen(ctx context.Context) {
+	m.next.SubscribeInvalidations(ctx, m.purge)
+}
+
+func (m *memoryCache) Stats() Stats {
+	m.mu.Lock()
+	size := m.order.Len()
+	m.mu.Unlock()
+
+	return Stats{
+		Hits:   m.hits.Load(),
+		Misses: m.misses.Load(),
+		Size:   size,
+	}
+}
+
+func (m *memoryCache) get(key memoryKey) (models.Banner, time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return models.Banner{}, 0, false
+	}
+
+	entry := el.Value.(*memoryEntry)
+	now := time.Now()
+	if !now.Before(entry.localExpiresAt) {
+		m.order.Remove(el)
+		delete(m.entries, key)
+		return models.Banner{}, 0, false
+	}
+
+	m.order.MoveToFront(el)
+	return entry.banner, entry.expiresAt.Sub(now), true
+}
+
+func (m *memoryCache) purgeCount() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.purges
+}
+
+// put stores the entry unless a purge ran since purges was read, the banner
+// may then be one the purge was meant to drop.
+func (m *memoryCache) put(key memoryKey, banner models.Banner, ttl time.Duration, purges uint64) {
+	now := time.Now()
+	entry := &memoryEntry{
+		key:            key,
+		banner:         banner,
+		expiresAt:      now.Add(ttl),
+		localExpiresAt: now.Add(min(ttl, m.ttl)),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.purges != purges {
+		return
+	}
+
+	if el, ok := m.entries[key]; ok {
+		el.Value = entry
+		m.order.MoveToFront(el)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(entry)
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
+
+// purge drops the pairs in every locale.
+func (m *memoryCache) purge(keys []models.BannerKey) {
+	purged := make(map[models.BannerKey]struct{}, len(keys))
+	for _, key := range keys {
+		purged[key] = struct{}{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purges++
+	for k, el := range m.entries {
+		if _, ok := purged[models.BannerKey{TagID: k.tagID, FeatureID: k.featureID}]; ok {
+			m.order.Remove(el)
+			delete(m.entries, k)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"project/internal/app/models"
+	"project/internal/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRemote struct {
+	banners map[memoryKey]models.Banner
+	gets    int
+	// onGet runs inside GetBanner before the banner is returned.
+	onGet func()
+	// subscribed receives the handler passed to SubscribeInvalidations.
+	subscribed chan func(keys []models.BannerKey)
+}
+
+func (f *fakeRemote) Generation(ctx context.Context, tagID int, featureID int) (uint64, error) {
+	return 0, nil
+}
+
+func (f *fakeRemote) SetBanner(ctx context.Context, tagID int, featureID int, locale string, banner models.Banner, generation uint64) error {
+	f.banners[memoryKey{tagID: tagID, featureID: featureID, locale: locale}] = banner
+	return nil
+}
+
+func (f *fakeRemote) GetBanner(ctx context.Context, tagID int, featureID int, locale string) (models.Banner, time.Duration, error) {
+	f.gets++
+	banner, ok := f.banners[memoryKey{tagID: tagID, featureID: featureID, locale: locale}]
+	if f.onGet != nil {
+		f.onGet()
+	}
+	if !ok {
+		return models.Banner{}, 0, models.BannerNotFound
+	}
+	return banner, f.TTL(), nil
+}
+
+func (f *fakeRemote) InvalidateBanners(ctx context.Context, keys []models.BannerKey) error {
+	for _, key := range keys {
+		for k := range f.banners {
+			if k.tagID == key.TagID && k.featureID == key.FeatureID {
+				delete(f.banners, k)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeRemote) SubscribeInvalidations(ctx context.Context, handle func(keys []models.BannerKey)) {
+	if f.subscribed != nil {
+		f.subscribed <- handle
+	}
+	<-ctx.Done()
+}
+
+func (f *fakeRemote) TTL() time.Duration {
+	return time.Minute
+}
+
+func TestMemoryCache_GetBanner(t *testing.T) {
+	remote := &fakeRemote{banners: map[memoryKey]models.Banner{}}
+	mc := newMemoryCache(logger.New(), remote, 2, time.Minute)
+	ctx := context.Background()
+
+	if err := mc.SetBanner(ctx, 1, 1, "ru", models.Banner{ID: 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	banner, ttl, err := mc.GetBanner(ctx, 1, 1, "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if banner.ID != 1 || ttl <= 0 {
+		t.Errorf("unexpected banner %d with ttl %s", banner.ID, ttl)
+	}
+	if remote.gets != 0 {
+		t.Errorf("expected no remote reads, got %d", remote.gets)
+	}
+
+	if _, _, err := mc.GetBanner(ctx, 2, 2, "ru"); !errors.Is(err, models.BannerNotFound) {
+		t.Errorf("expected BannerNotFound, got %v", err)
+	}
+
+	stats := mc.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestMemoryCache_Evict(t *testing.T) {
+	remote := &fakeRemote{banners: map[memoryKey]models.Banner{}}
+	mc := newMemoryCache(logger.New(), remote, 2, time.Minute)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		if err := mc.SetBanner(ctx, i, i, "ru", models.Banner{ID: i}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if mc.Stats().Size != 2 {
+		t.Errorf("expected size 2, got %d", mc.Stats().Size)
+	}
+
+	if _, _, err := mc.GetBanner(ctx, 1, 1, "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if remote.gets != 1 {
+		t.Errorf("expected evicted entry to be read from remote, got %d reads", remote.gets)
+	}
+}
+
+func TestMemoryCache_InvalidateBanners(t *testing.T) {
+	remote := &fakeRemote{banners: map[memoryKey]models.Banner{}}
+	mc := newMemoryCache(logger.New(), remote, 2, time.Minute)
+	ctx := context.Background()
+
+	for _, locale := range []string{"ru", "en"} {
+		if err := mc.SetBanner(ctx, 1, 1, locale, models.Banner{ID: 1, Locale: locale}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := mc.InvalidateBanners(ctx, []models.BannerKey{{TagID: 1, FeatureID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, locale := range []string{"ru", "en"} {
+		if _, _, err := mc.GetBanner(ctx, 1, 1, locale); !errors.Is(err, models.BannerNotFound) {
+			t.Errorf("expected BannerNotFound in %s after invalidation, got %v", locale, err)
+		}
+	}
+}
+
+func TestMemoryCache_ListenDuringRemoteRead(t *testing.T) {
+	remote := &fakeRemote{
+		banners:    map[memoryKey]models.Banner{{tagID: 1, featureID: 1, locale: "ru"}: {ID: 1}},
+		subscribed: make(chan func(keys []models.BannerKey)),
+	}
+	mc := newMemoryCache(logger.New(), remote, 2, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go mc.Listen(ctx)
+	handle := <-remote.subscribed
+	// Another instance invalidates the pair while the banner is on its way.
+	remote.onGet = func() {
+		remote.onGet = nil
+		handle([]models.BannerKey{{TagID: 1, FeatureID: 1}})
+	}
+
+	if _, _, err := mc.GetBanner(ctx, 1, 1, "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := mc.GetBanner(ctx, 1, 1, "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if remote.gets != 2 {
+		t.Errorf("expected the banner read during the invalidation not to be kept, got %d remote reads", remote.gets)
+	}
+}
+
+// recordingLogger sends Infof lines to infos, dropping them while nobody
+// is receiving.
+type recordingLogger struct {
+	logger.Logger
+	infos chan string
+}
+
+func (r *recordingLogger) Infof(format string, args ...any) {
+	select {
+	case r.infos <- fmt.Sprintf(format, args...):
+	default:
+	}
+}
+
+func TestMemoryCache_RunStatsLogger(t *testing.T) {
+	remote := &fakeRemote{banners: map[memoryKey]models.Banner{}}
+	log := &recordingLogger{Logger: logger.New(), infos: make(chan string)}
+	mc := newMemoryCache(log, remote, 2, time.Minute)
+	mc.statsInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, _, err := mc.GetBanner(ctx, 1, 1, "ru"); !errors.Is(err, models.BannerNotFound) {
+		t.Fatalf("expected a miss, got %v", err)
+	}
+
+	go mc.RunStatsLogger(ctx)
+	select {
+	case line := <-log.infos:
+		if !strings.Contains(line, "hits 0, misses 1, size 0 of 2") {
+			t.Errorf("unexpected stats line %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the stats to be logged")
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"project/internal/app/models"
+	"time"
+)
+
+const apiKeyColumns = `id, name, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+// apiKeyTouchInterval bounds how often using a key writes last_used_at.
+const apiKeyTouchInterval = time.Minute
+
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &expiresAt, &lastUsedAt, &revokedAt, &key.CreatedAt)
+	key.ExpiresAt = fromNullTime(expiresAt)
+	key.LastUsedAt = fromNullTime(lastUsedAt)
+	key.RevokedAt = fromNullTime(revokedAt)
+	return key, err
+}
+
+func (r *repository) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error) {
+	const op = "repository.CreateAPIKey"
+
+	created, err := scanAPIKey(r.db.QueryRowContext(ctx, `INSERT INTO api_keys (name, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4)
+RETURNING `+apiKeyColumns, key.Name, keyHash, pq.Array(key.Scopes), toNullTime(key.ExpiresAt)))
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return models.APIKey{}, err
+	}
+
+	return created, nil
+}
+
+// GetAPIKeyByHash returns the key with the hash, revoked and expired ones
+// included, the caller decides whether it is usable.
+func (r *repository) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	const op = "repository.GetAPIKeyByHash"
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash=$1`, keyHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, models.APIKeyNotFound
+		}
+		r.log.Errorf("%s Failed to scan row: %s", op, err)
+		return models.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *repository) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const op = "repository.GetAPIKeys"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.log.Errorf("%s Failed to scan row: %s", op, err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// TouchAPIKey records a use of the key, at most once per apiKeyTouchInterval.
+func (r *repository) TouchAPIKey(ctx context.Context, id int) error {
+	const op = "repository.TouchAPIKey"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at=now()
+WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < now() - make_interval(secs => $2))`, id, apiKeyTouchInterval.Seconds())
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return err
+	}
+
+	return nil
+}
+
+// RevokeAPIKey returns models.APIKeyNotFound for unknown and already revoked keys.
+func (r *repository) RevokeAPIKey(ctx context.Context, id int) error {
+	const op = "repository.RevokeAPIKey"
+
+	res, err := r.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return err
+	}
+
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		r.log.Errorf("%s Failed to get affected rows: %s", op, err)
+		return err
+	}
+	if revoked == 0 {
+		return models.APIKeyNotFound
+	}
+
+	return nil
+}
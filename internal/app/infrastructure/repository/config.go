@@ -3,8 +3,11 @@ package repository
 import (
 	"errors"
 	"os"
+	"strconv"
 )
 
+const defaultHistorySize = 3
+
 type dbConfig struct {
 	Host     string
 	Port     string
@@ -12,6 +15,8 @@ type dbConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+
+	HistorySize int
 }
 
 func loadConfig() (*dbConfig, error) {
@@ -39,12 +44,21 @@ func loadConfig() (*dbConfig, error) {
 	if sslMode == "" {
 		return nil, errors.New("DB_SSL environment variable not set")
 	}
+	historySize := defaultHistorySize
+	if hs := os.Getenv("BANNER_HISTORY_SIZE"); hs != "" {
+		hsInt, err := strconv.Atoi(hs)
+		if err != nil || hsInt < 1 {
+			return nil, errors.New("BANNER_HISTORY_SIZE environment variable not valid")
+		}
+		historySize = hsInt
+	}
 	return &dbConfig{
-		Host:     host,
-		Port:     port,
-		User:     user,
-		Password: password,
-		Name:     name,
-		SSLMode:  sslMode,
+		Host:        host,
+		Port:        port,
+		User:        user,
+		Password:    password,
+		Name:        name,
+		SSLMode:     sslMode,
+		HistorySize: historySize,
 	}, nil
 }
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"project/internal/logger"
+	"testing"
+	"time"
+)
+
+// testRepository returns a repository on a fresh schema of the database named
+// by TEST_DATABASE_URL, the test is skipped without it.
+func testRepository(t *testing.T) *repository {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Error(err)
+		}
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	db, err := sql.Open("pgx", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &repository{db: db, log: logger.New(), historySize: defaultHistorySize}
+}
+
+// baselineSchema is scripts/init.sql as databases were created before migrations.
+const baselineSchema = `create table banners (
+    id serial,
+    tag_ids integer[],
+    feature_id integer,
+    primary key (tag_ids, feature_id),
+    content json,
+    is_active bool,
+    created_at timestamp default now(),
+    updated_at timestamp default now()
+);
+
+create or replace function set_updated_at() returns trigger as
+$$
+    BEGIN
+        NEW.updated_at := current_timestamp;
+        RETURN NEW;
+    END;
+$$ LANGUAGE plpgsql;
+
+create or replace trigger update_trigger before update on banners
+    for statement execute procedure set_updated_at();`
+
+func execAll(t *testing.T, r *repository, statements ...string) {
+	for _, statement := range statements {
+		if _, err := r.db.ExecContext(context.Background(), statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"fmt"
+	"project/internal/app/models"
+	"strings"
+)
+
+var scheduleConditions = map[models.ScheduleStatus]string{
+	models.ScheduleScheduled: `active_from > now()`,
+	models.ScheduleLive:      `(active_from IS NULL OR active_from <= now()) AND (active_until IS NULL OR active_until > now())`,
+	models.ScheduleExpired:   `active_until <= now()`,
+}
+
+// whereClause accumulates AND-ed conditions together with their positional arguments.
+type whereClause struct {
+	conditions []string
+	args       []any
+}
+
+// add appends a condition, every %d in cond is replaced with the position of the matching arg.
+func (w *whereClause) add(cond string, args ...any) {
+	positions := make([]any, len(args))
+	for i, arg := range args {
+		w.args = append(w.args, arg)
+		positions[i] = len(w.args)
+	}
+	w.conditions = append(w.conditions, fmt.Sprintf(cond, positions...))
+}
+
+func (w *whereClause) String() string {
+	if len(w.conditions) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(w.conditions, " AND ")
+}
+
+func bannerFilterClause(filter models.BannerFilter) *whereClause {
+	w := &whereClause{}
+
+	if filter.TagID != nil {
+		w.add(`id IN (SELECT banner_id FROM banner_tags WHERE tag_id=$%d)`, *filter.TagID)
+	}
+	if filter.FeatureID != nil {
+		w.add(`feature_id=$%d`, *filter.FeatureID)
+	}
+	if filter.IsActive != nil {
+		w.add(`is_active=$%d`, *filter.IsActive)
+	}
+	if cond, ok := scheduleConditions[filter.Schedule]; ok {
+		w.add(cond)
+	}
+	if filter.CreatedFrom != nil {
+		w.add(`created_at >= $%d`, *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		w.add(`created_at < $%d`, *filter.CreatedTo)
+	}
+	if filter.UpdatedFrom != nil {
+		w.add(`updated_at >= $%d`, *filter.UpdatedFrom)
+	}
+	if filter.UpdatedTo != nil {
+		w.add(`updated_at < $%d`, *filter.UpdatedTo)
+	}
+	if filter.ContentKey != "" {
+		w.add(`content::jsonb ? $%d`, filter.ContentKey)
+	}
+	if filter.Cursor != nil {
+		if filter.Cursor.Backward {
+			w.add(`(created_at, id) < ($%d, $%d)`, filter.Cursor.CreatedAt, filter.Cursor.ID)
+		} else {
+			w.add(`(created_at, id) > ($%d, $%d)`, filter.Cursor.CreatedAt, filter.Cursor.ID)
+		}
+	}
+
+	return w
+}
+
+// bannerOrder is the list order, reversed when paging backward from a cursor.
+func bannerOrder(filter models.BannerFilter) string {
+	if filter.Cursor != nil && filter.Cursor.Backward {
+		return `created_at DESC, id DESC`
+	}
+	return `created_at, id`
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"project/internal/app/models"
+	"testing"
+)
+
+func TestBannerFilterClause(t *testing.T) {
+	tagID, featureID, active := 1, 2, true
+	w := bannerFilterClause(models.BannerFilter{
+		TagID:      &tagID,
+		FeatureID:  &featureID,
+		IsActive:   &active,
+		Schedule:   models.ScheduleExpired,
+		ContentKey: "title",
+	})
+
+	expected := `id IN (SELECT banner_id FROM banner_tags WHERE tag_id=$1) AND feature_id=$2 AND is_active=$3 AND active_until <= now() AND content::jsonb ? $4`
+	if w.String() != expected {
+		t.Errorf("unexpected clause:\n%s\nwant:\n%s", w.String(), expected)
+	}
+
+	if len(w.args) != 4 {
+		t.Errorf("expected 4 args, got %d", len(w.args))
+	}
+
+	if empty := bannerFilterClause(models.BannerFilter{}); empty.String() != "TRUE" || len(empty.args) != 0 {
+		t.Errorf("expected empty filter to match everything, got %s", empty.String())
+	}
+}
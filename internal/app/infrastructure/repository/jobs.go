@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"project/internal/app/models"
+	"time"
+)
+
+const jobColumns = `id, feature_id, tag_id, status, total, processed, error, created_at, updated_at`
+
+// staleJobTimeout is how long a running job may go without progress before
+// another worker takes it over, its owner has most likely stopped.
+const staleJobTimeout = 5 * time.Minute
+
+// CreateDeleteJob enqueues deletion of the banners matching the job's feature
+// and tag and returns the stored job.
+func (r *repository) CreateDeleteJob(ctx context.Context, featureID *int, tagID *int) (models.DeleteJob, error) {
+	const op = "repository.CreateDeleteJob"
+
+	job := models.DeleteJob{FeatureID: featureID, TagID: tagID}
+	where := bannerFilterClause(job.Filter())
+	args := append(where.args, toNullInt(featureID), toNullInt(tagID))
+	query := fmt.Sprintf(`INSERT INTO banner_jobs (feature_id, tag_id, total)
+SELECT $%d::integer, $%d::integer, COUNT(*) FROM banners WHERE deleted_at IS NULL AND %s
+RETURNING `+jobColumns, len(args)-1, len(args), where)
+	row := r.db.QueryRowContext(ctx, query, args...)
+	job, err := scanJob(row)
+	if err != nil {
+		r.log.Errorf("%s Failed to insert job: %s", op, err)
+		return models.DeleteJob{}, err
+	}
+
+	return job, nil
+}
+
+func (r *repository) GetDeleteJob(ctx context.Context, jobID int) (models.DeleteJob, error) {
+	const op = "repository.GetDeleteJob"
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM banner_jobs WHERE id=$1`, jobID)
+	job, err := scanJob(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DeleteJob{}, models.JobNotFound
+		}
+		r.log.Errorf("%s Failed to scan row: %s", op, err)
+		return models.DeleteJob{}, err
+	}
+
+	return job, nil
+}
+
+// ClaimDeleteJob marks the oldest pending (or stale running) job as running
+// and returns it, models.JobNotFound if there is nothing to do.
+func (r *repository) ClaimDeleteJob(ctx context.Context) (models.DeleteJob, error) {
+	const op = "repository.ClaimDeleteJob"
+
+	row := r.db.QueryRowContext(ctx, `UPDATE banner_jobs SET status=$1, updated_at=now()
+WHERE id = (
+	SELECT id FROM banner_jobs
+	WHERE status=$2 OR (status=$1 AND updated_at < now() - make_interval(secs => $3))
+	ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED
+)
+RETURNING `+jobColumns, string(models.JobRunning), string(models.JobPending), staleJobTimeout.Seconds())
+	job, err := scanJob(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DeleteJob{}, models.JobNotFound
+		}
+		r.log.Errorf("%s Failed to claim job: %s", op, err)
+		return models.DeleteJob{}, err
+	}
+
+	return job, nil
+}
+
+// UpdateDeleteJob saves the job's status, progress and error.
+func (r *repository) UpdateDeleteJob(ctx context.Context, job models.DeleteJob) error {
+	const op = "repository.UpdateDeleteJob"
+
+	_, err := r.db.ExecContext(ctx, `UPDATE banner_jobs SET status=$1, total=$2, processed=$3, error=$4, updated_at=now() WHERE id=$5`,
+		string(job.Status), job.Total, job.Processed, job.Error, job.ID)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteBannersBatch deletes up to limit banners matching the filter the same
+// way DeleteBanner does and returns them. Banners locked by a concurrent write
+// are skipped, so an empty batch does not mean none are left, see HasBanners.
+func (r *repository) DeleteBannersBatch(ctx context.Context, filter models.BannerFilter, limit int) ([]models.Banner, error) {
+	const op = "repository.DeleteBannersBatch"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Errorf("%s Failed to begin transaction: %s", op, err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	where := bannerFilterClause(filter)
+	args := append(where.args, limit)
+	query := fmt.Sprintf(`UPDATE banners SET deleted_at=now(), version=version+1
+WHERE id IN (
+	SELECT id FROM banners WHERE deleted_at IS NULL AND %s
+	ORDER BY id LIMIT $%d FOR UPDATE SKIP LOCKED
+)
+RETURNING `+bannerColumns, where, len(args))
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return nil, err
+	}
+
+	banners, err := r.scanBanners(op, rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int32, len(banners))
+	for i, banner := range banners {
+		ids[i] = int32(banner.ID)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM banner_tags WHERE banner_id=ANY($1)`, pq.Array(ids)); err != nil {
+		r.log.Errorf("%s Failed to release banner tags: %s", op, err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Errorf("%s Failed to commit transaction: %s", op, err)
+		return nil, err
+	}
+
+	return banners, nil
+}
+
+func scanJob(row rowScanner) (models.DeleteJob, error) {
+	var (
+		job       models.DeleteJob
+		featureID sql.NullInt64
+		tagID     sql.NullInt64
+	)
+	err := row.Scan(&job.ID, &featureID, &tagID, &job.Status, &job.Total, &job.Processed, &job.Error, &job.CreatedAt, &job.UpdatedAt)
+	job.FeatureID = fromNullInt(featureID)
+	job.TagID = fromNullInt(tagID)
+	return job, err
+}
+
+// HasBanners reports whether any banner matching the filter is left. Unlike
+// DeleteBannersBatch it takes no locks, so it sees banners a concurrent write
+// holds as well.
+func (r *repository) HasBanners(ctx context.Context, filter models.BannerFilter) (bool, error) {
+	const op = "repository.HasBanners"
+
+	where := bannerFilterClause(filter)
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM banners WHERE deleted_at IS NULL AND %s)`, where)
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, where.args...).Scan(&exists); err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return false, err
+	}
+
+	return exists, nil
+}
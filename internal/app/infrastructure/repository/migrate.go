@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// migrationLockID is the advisory lock key held while migrating, so replicas
+// starting together apply every migration once.
+const migrationLockID = 4_372_017_110
+
+// MigrateUp applies every pending migration in order, each in its own transaction.
+func (r *repository) MigrateUp(ctx context.Context) error {
+	const op = "repository.MigrateUp"
+
+	unlock, err := r.lockMigrations(ctx)
+	if err != nil {
+		r.log.Errorf("%s Failed to lock migrations: %s", op, err)
+		return err
+	}
+	defer unlock()
+
+	migrations, applied, err := r.migrationState(ctx)
+	if err != nil {
+		r.log.Errorf("%s Failed to read migration state: %s", op, err)
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := r.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			return err
+		})
+		if err != nil {
+			r.log.Errorf("%s Failed to apply migration %04d_%s: %s", op, m.Version, m.Name, err)
+			return err
+		}
+		r.log.Infof("%s Applied migration %04d_%s", op, m.Version, m.Name)
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the most recently applied migration.
+func (r *repository) MigrateDown(ctx context.Context) error {
+	const op = "repository.MigrateDown"
+
+	unlock, err := r.lockMigrations(ctx)
+	if err != nil {
+		r.log.Errorf("%s Failed to lock migrations: %s", op, err)
+		return err
+	}
+	defer unlock()
+
+	migrations, applied, err := r.migrationState(ctx)
+	if err != nil {
+		r.log.Errorf("%s Failed to read migration state: %s", op, err)
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err := r.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, m.Down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version=$1`, m.Version)
+			return err
+		})
+		if err != nil {
+			r.log.Errorf("%s Failed to revert migration %04d_%s: %s", op, m.Version, m.Name, err)
+			return err
+		}
+		r.log.Infof("%s Reverted migration %04d_%s", op, m.Version, m.Name)
+		return nil
+	}
+
+	r.log.Infof("%s Nothing to revert", op)
+	return nil
+}
+
+func (r *repository) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	const op = "repository.MigrationStatus"
+
+	migrations, applied, err := r.migrationState(ctx)
+	if err != nil {
+		r.log.Errorf("%s Failed to read migration state: %s", op, err)
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		statuses[i] = MigrationStatus{Version: m.Version, Name: m.Name}
+		if appliedAt, ok := applied[m.Version]; ok {
+			statuses[i].AppliedAt = &appliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+// CheckSchema returns an error if some of the embedded migrations have not been applied.
+func (r *repository) CheckSchema(ctx context.Context) error {
+	statuses, err := r.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", status.Version, status.Name))
+		}
+	}
+
+	if len(pending) != 0 {
+		return fmt.Errorf("database schema is behind, pending migrations: %s", strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+func (r *repository) migrationState(ctx context.Context) ([]migration, map[int]time.Time, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = r.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version integer primary key,
+    name text not null,
+    applied_at timestamptz not null default now()
+)`)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return migrations, applied, rows.Err()
+}
+
+// lockMigrations waits for the migration advisory lock. The lock belongs to a
+// session, so it is taken on a dedicated connection released by unlock.
+func (r *repository) lockMigrations(ctx context.Context) (unlock func(), err error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, int64(migrationLockID)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, int64(migrationLockID)); err != nil {
+			r.log.Errorf("repository.lockMigrations Failed to release lock: %s", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+func (r *repository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadMigrations pairs the embedded NNNN_name.up.sql and NNNN_name.down.sql
+// files and returns them ordered by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(fileName, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("unexpected migration file %s", fileName)
+		}
+
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("unexpected migration file %s", fileName)
+		}
+
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected migration version in %s", fileName)
+		}
+
+		content, err := migrationFiles.ReadFile("migrations/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %04d has conflicting names %s and %s", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, errors.New("migration " + strconv.Itoa(m.Version) + " must have both up and down files")
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
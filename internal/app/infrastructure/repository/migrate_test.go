@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("expected embedded migrations")
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("expected migration version %d, got %d (%s)", i+1, m.Version, m.Name)
+		}
+	}
+}
+
+func TestRepository_MigrateUpBaseline(t *testing.T) {
+	r := testRepository(t)
+	ctx := context.Background()
+	execAll(t, r, baselineSchema,
+		`insert into banners (tag_ids, feature_id, content, is_active) values ('{1,2}', 1, '{"title":"a"}', true)`)
+
+	if err := r.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CheckSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var tags int
+	if err := r.db.QueryRowContext(ctx, `select count(*) from banner_tags`).Scan(&tags); err != nil {
+		t.Fatal(err)
+	}
+	if tags != 2 {
+		t.Errorf("expected the baseline banner tags to be moved, got %d rows", tags)
+	}
+}
+
+func TestRepository_MigrateUpConcurrent(t *testing.T) {
+	r := testRepository(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = r.MigrateUp(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("expected concurrent migrations to wait for each other, got %v", err)
+		}
+	}
+	if err := r.CheckSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepository_MigrateUpDuplicateTags(t *testing.T) {
+	r := testRepository(t)
+	ctx := context.Background()
+	execAll(t, r, baselineSchema,
+		`insert into banners (id, tag_ids, feature_id, content, is_active) values
+    (1, '{1,2}', 1, '{}', true),
+    (2, '{2,3}', 1, '{}', true),
+    (3, '{2}', 2, '{}', true)`)
+
+	err := r.MigrateUp(ctx)
+	if err == nil || !strings.Contains(err.Error(), "tag 2 feature 1: banners {1,2}") {
+		t.Fatalf("expected the clashing banners to be reported, got %v", err)
+	}
+
+	statuses, err := r.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Version >= 4 && status.AppliedAt != nil {
+			t.Errorf("expected migration %04d_%s not to be applied", status.Version, status.Name)
+		}
+	}
+}
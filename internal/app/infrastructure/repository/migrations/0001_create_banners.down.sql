@@ -0,0 +1,3 @@
+drop trigger if exists update_trigger on banners;
+drop function if exists set_updated_at();
+drop table if exists banners;
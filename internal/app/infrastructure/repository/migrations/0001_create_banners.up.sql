@@ -0,0 +1,32 @@
+create table if not exists banners (
+    id serial unique,
+    tag_ids integer[],
+    feature_id integer,
+    primary key (tag_ids, feature_id),
+    content json,
+    is_active bool,
+    created_at timestamp default now(),
+    updated_at timestamp default now()
+);
+
+-- Databases created by the old scripts/init.sql already have the table, but
+-- without the unique id later migrations reference.
+do $$
+begin
+    if not exists (select 1 from pg_constraint where conrelid = 'banners'::regclass and conname = 'banners_id_key') then
+        alter table banners add constraint banners_id_key unique (id);
+    end if;
+end
+$$;
+
+create or replace function set_updated_at() returns trigger as
+$$
+    BEGIN
+        NEW.updated_at := current_timestamp;
+        RETURN NEW;
+    END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the for statement trigger of init.sql, which never set updated_at.
+create or replace trigger update_trigger before update on banners
+    for each row execute procedure set_updated_at();
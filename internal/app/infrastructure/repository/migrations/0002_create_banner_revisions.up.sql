@@ -0,0 +1,11 @@
+create table if not exists banner_revisions (
+    banner_id integer references banners (id) on delete cascade,
+    version integer,
+    primary key (banner_id, version),
+    tag_ids integer[],
+    feature_id integer,
+    content json,
+    is_active bool,
+    author text,
+    created_at timestamp default now()
+);
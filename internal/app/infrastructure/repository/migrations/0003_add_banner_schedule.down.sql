@@ -0,0 +1,7 @@
+alter table banner_revisions
+    drop column if exists active_from,
+    drop column if exists active_until;
+
+alter table banners
+    drop column if exists active_from,
+    drop column if exists active_until;
@@ -0,0 +1,7 @@
+alter table banners
+    add column if not exists active_from timestamptz,
+    add column if not exists active_until timestamptz;
+
+alter table banner_revisions
+    add column if not exists active_from timestamptz,
+    add column if not exists active_until timestamptz;
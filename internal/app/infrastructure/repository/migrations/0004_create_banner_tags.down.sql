@@ -0,0 +1,4 @@
+alter table banners drop constraint if exists banners_pkey;
+alter table banners add primary key (tag_ids, feature_id);
+
+drop table if exists banner_tags;
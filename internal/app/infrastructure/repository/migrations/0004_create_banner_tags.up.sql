@@ -0,0 +1,35 @@
+create table if not exists banner_tags (
+    banner_id integer not null references banners (id) on delete cascade,
+    tag_id integer not null,
+    feature_id integer not null,
+    primary key (banner_id, tag_id),
+    unique (tag_id, feature_id)
+);
+
+-- Banners sharing a (tag_id, feature_id) pair cannot be told apart on the user
+-- path. They must be fixed by hand, picking one silently would leave tag_ids
+-- and banner_tags disagreeing.
+do $$
+declare
+    clashes text;
+begin
+    select string_agg(format('tag %s feature %s: banners %s', tag_id, feature_id, ids), '; ')
+    into clashes
+    from (
+        select tag_id, feature_id, array_agg(distinct id order by id) as ids
+        from (select id, unnest(tag_ids) as tag_id, feature_id from banners) t
+        group by tag_id, feature_id
+        having count(distinct id) > 1
+    ) d;
+
+    if clashes is not null then
+        raise exception 'banners share tag and feature pairs, fix them before migrating: %', clashes;
+    end if;
+end
+$$;
+
+insert into banner_tags (banner_id, tag_id, feature_id)
+select distinct id, unnest(tag_ids), feature_id from banners;
+
+alter table banners drop constraint if exists banners_pkey;
+alter table banners add primary key (id);
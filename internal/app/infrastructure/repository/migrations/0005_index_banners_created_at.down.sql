@@ -0,0 +1 @@
+drop index if exists banners_created_at_id_idx;
@@ -0,0 +1 @@
+create index if not exists banners_created_at_id_idx on banners (created_at, id);
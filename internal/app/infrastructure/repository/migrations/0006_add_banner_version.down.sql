@@ -0,0 +1 @@
+alter table banners drop column if exists version;
@@ -0,0 +1,8 @@
+drop index if exists banners_deleted_at_idx;
+
+-- Without deleted_at a deleted banner would come back live while its tag pairs
+-- are already free in banner_tags, so deleted banners are purged for good here,
+-- revisions included.
+delete from banners where deleted_at is not null;
+
+alter table banners drop column if exists deleted_at;
@@ -0,0 +1,3 @@
+alter table banners add column if not exists deleted_at timestamptz;
+
+create index if not exists banners_deleted_at_idx on banners (deleted_at) where deleted_at is not null;
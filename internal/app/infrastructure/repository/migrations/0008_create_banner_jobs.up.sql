@@ -0,0 +1,13 @@
+create table if not exists banner_jobs (
+    id serial primary key,
+    feature_id integer,
+    tag_id integer,
+    status text not null default 'pending',
+    total integer not null default 0,
+    processed integer not null default 0,
+    error text not null default '',
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+create index if not exists banner_jobs_unfinished_idx on banner_jobs (id) where status in ('pending', 'running');
@@ -0,0 +1,5 @@
+create table if not exists feature_schemas (
+    feature_id integer primary key,
+    schema jsonb not null,
+    updated_at timestamptz not null default now()
+);
@@ -0,0 +1,3 @@
+alter table banner_revisions drop column if exists localized_content;
+
+alter table banners drop column if exists localized_content;
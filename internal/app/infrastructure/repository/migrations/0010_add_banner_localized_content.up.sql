@@ -0,0 +1,3 @@
+alter table banners add column if not exists localized_content json not null default '{}';
+
+alter table banner_revisions add column if not exists localized_content json;
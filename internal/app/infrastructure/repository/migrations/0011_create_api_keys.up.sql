@@ -0,0 +1,10 @@
+create table if not exists api_keys (
+    id serial primary key,
+    name text not null,
+    key_hash text not null unique,
+    scopes text[] not null default '{}',
+    expires_at timestamptz,
+    last_used_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz not null default now()
+);
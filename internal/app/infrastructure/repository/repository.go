@@ -9,11 +9,13 @@ import (
 	"github.com/lib/pq"
 	"project/internal/app/models"
 	"project/internal/logger"
+	"time"
 )
 
 type repository struct {
-	db  *sql.DB
-	log logger.Logger
+	db          *sql.DB
+	log         logger.Logger
+	historySize int
 }
 
 func New(log logger.Logger) (*repository, error) {
@@ -32,36 +34,22 @@ func New(log logger.Logger) (*repository, error) {
 	}
 
 	return &repository{
-		log: log,
-		db:  db,
+		log:         log,
+		db:          db,
+		historySize: cfg.HistorySize,
 	}, nil
 }
 
 func (r *repository) GetBanner(ctx context.Context, tagID int, featureID int) (models.Banner, error) {
 	const op = "repository.GetBanner"
 
-	row := r.db.QueryRowContext(ctx, `SELECT id, tag_ids, feature_id, content, is_active, created_at, updated_at
-FROM banners WHERE $1=ANY(tag_ids) AND feature_id = $2`, tagID, featureID)
-	if err := row.Err(); err != nil {
+	row := r.db.QueryRowContext(ctx, `SELECT `+bannerColumns+`
+FROM banners WHERE id = (SELECT banner_id FROM banner_tags WHERE tag_id=$1 AND feature_id=$2) AND deleted_at IS NULL`, tagID, featureID)
+	bannerDB, err := scanBanner(row)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.Banner{}, models.BannerNotFound
 		}
-		r.log.Errorf("%s Failed to execute query: %s", op, err)
-		return models.Banner{}, err
-	}
-
-	var bannerDB dbBanner
-	err := row.Scan(
-		&bannerDB.ID,
-		pq.Array(&bannerDB.TagIDs),
-		&bannerDB.FeatureID,
-		&bannerDB.Content,
-		&bannerDB.IsActive,
-		&bannerDB.CreatedAt,
-		&bannerDB.UpdatedAt,
-	)
-
-	if err != nil {
 		r.log.Errorf("%s Failed to scan row: %s", op, err)
 		return models.Banner{}, err
 	}
@@ -70,134 +58,167 @@ FROM banners WHERE $1=ANY(tag_ids) AND feature_id = $2`, tagID, featureID)
 	return banner, nil
 }
 
-func (r *repository) GetBanners(ctx context.Context, limit, offset int) ([]models.Banner, error) {
+// GetBanners returns banners matching the filter. With a backward cursor the
+// banners come in descending (created_at, id) order.
+func (r *repository) GetBanners(ctx context.Context, filter models.BannerFilter) ([]models.Banner, error) {
 	const op = "repository.GetBanners"
-	rows, err := r.db.QueryContext(ctx, `SELECT id, tag_ids, feature_id, content, is_active, created_at, updated_at
-FROM banners ORDER BY created_at LIMIT $1 OFFSET $2`, limit, offset)
+
+	offset := filter.Offset
+	if filter.Cursor != nil {
+		offset = 0
+	}
+
+	where := bannerFilterClause(filter)
+	args := append(where.args, filter.Limit, offset)
+	query := fmt.Sprintf(`SELECT `+bannerColumns+`
+FROM banners WHERE deleted_at IS NULL AND %s ORDER BY %s LIMIT $%d OFFSET $%d`, where, bannerOrder(filter), len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.log.Errorf("%s Failed to execute query: %s", op, err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	banners := make([]models.Banner, 0)
-	for rows.Next() {
-		var bannerDB dbBanner
-		err := rows.Scan(
-			&bannerDB.ID,
-			pq.Array(&bannerDB.TagIDs),
-			&bannerDB.FeatureID,
-			&bannerDB.Content,
-			&bannerDB.IsActive,
-			&bannerDB.CreatedAt,
-			&bannerDB.UpdatedAt,
-		)
-		if err != nil {
-			r.log.Errorf("%s Failed to scan row: %s", op, err)
-			return nil, err
-		}
-
-		banner := mapOnBanner(bannerDB)
-		banners = append(banners, banner)
-	}
-
-	return banners, nil
+	return r.scanBanners(op, rows)
 }
 
-func (r *repository) GetBannersByTagID(ctx context.Context, tagID int, limit int, offset int) ([]models.Banner, error) {
-	const op = "repository.GetBannersByTagID"
+// GetBannersWithTotal returns a page of banners matching the filter together
+// with the number of all matching banners.
+func (r *repository) GetBannersWithTotal(ctx context.Context, filter models.BannerFilter) ([]models.Banner, int, error) {
+	const op = "repository.GetBannersWithTotal"
+
+	where := bannerFilterClause(filter)
+	args := append(where.args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`SELECT `+bannerColumns+`, COUNT(*) OVER()
+FROM banners WHERE deleted_at IS NULL AND %s ORDER BY created_at, id LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args))
 
-	rows, err := r.db.QueryContext(ctx, `SELECT id, tag_ids, feature_id, content, is_active, created_at, updated_at
-FROM banners WHERE $1=ANY(tag_ids) ORDER BY created_at LIMIT $2 OFFSET $3`, tagID, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		r.log.Errorf("%s Failed to execute query: %s", op, err)
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
+	var total int
 	banners := make([]models.Banner, 0)
 	for rows.Next() {
-		var bannerDB dbBanner
-		err := rows.Scan(
-			&bannerDB.ID,
-			pq.Array(&bannerDB.TagIDs),
-			&bannerDB.FeatureID,
-			&bannerDB.Content,
-			&bannerDB.IsActive,
-			&bannerDB.CreatedAt,
-			&bannerDB.UpdatedAt,
-		)
+		bannerDB, err := scanBanner(rows, &total)
 		if err != nil {
 			r.log.Errorf("%s Failed to scan row: %s", op, err)
+			return nil, 0, err
 		}
-		banner := mapOnBanner(bannerDB)
-		banners = append(banners, banner)
-	}
 
-	return banners, nil
-}
+		banners = append(banners, mapOnBanner(bannerDB))
+	}
 
-func (r *repository) GetBannersByFeatureID(ctx context.Context, featureID int, limit int, offset int) ([]models.Banner, error) {
-	const op = "repository.GetBannersByFeatureID"
+	if err := rows.Err(); err != nil {
+		r.log.Errorf("%s Failed to iterate rows: %s", op, err)
+		return nil, 0, err
+	}
 
-	rows, err := r.db.QueryContext(ctx, `SELECT id, tag_ids, feature_id, content, is_active, created_at, updated_at
-FROM banners WHERE feature_id=$1 ORDER BY created_at LIMIT $2 OFFSET $3`, featureID, limit, offset)
-	if err != nil {
-		r.log.Errorf("%s Failed to execute query: %s", op, err)
-		return nil, err
+	// The window count is only available along with rows, an offset past the
+	// end still needs the total.
+	if len(banners) == 0 && filter.Offset > 0 {
+		err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM banners WHERE deleted_at IS NULL AND `+where.String(), where.args...).Scan(&total)
+		if err != nil {
+			r.log.Errorf("%s Failed to count banners: %s", op, err)
+			return nil, 0, err
+		}
 	}
-	defer rows.Close()
 
+	return banners, total, nil
+}
+
+func (r *repository) scanBanners(op string, rows *sql.Rows) ([]models.Banner, error) {
 	banners := make([]models.Banner, 0)
 	for rows.Next() {
-		var bannerDB dbBanner
-		err := rows.Scan(
-			&bannerDB.ID,
-			pq.Array(&bannerDB.TagIDs),
-			&bannerDB.FeatureID,
-			&bannerDB.Content,
-			&bannerDB.IsActive,
-			&bannerDB.CreatedAt,
-			&bannerDB.UpdatedAt,
-		)
+		bannerDB, err := scanBanner(rows)
 		if err != nil {
 			r.log.Errorf("%s Failed to scan row: %s", op, err)
+			return nil, err
 		}
+
 		banner := mapOnBanner(bannerDB)
 		banners = append(banners, banner)
 	}
 
+	if err := rows.Err(); err != nil {
+		r.log.Errorf("%s Failed to iterate rows: %s", op, err)
+		return nil, err
+	}
+
 	return banners, nil
 }
 
-func (r *repository) UpdateBanner(ctx context.Context, banner models.Banner) (bool, error) {
+// UpdateBanner applies the patch to the banner under a row lock and returns
+// its state before and after the update. With non-nil ifMatch the banner
+// version must be one of them, otherwise models.VersionMismatch is returned.
+// check, if set, vets the banner and its patched state before anything else
+// is reported about it.
+func (r *repository) UpdateBanner(ctx context.Context, bannerID int, patch models.BannerPatch, ifMatch []int, check func(before models.Banner, after models.Banner) error, author string) (before models.Banner, after models.Banner, err error) {
 	const op = "repository.UpdateBanner"
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		r.log.Errorf("%s Failed to begin transaction: %s", op, err)
-		return false, err
+		return models.Banner{}, models.Banner{}, err
 	}
 	defer tx.Rollback()
 
+	before, err = r.lockBanner(ctx, tx, bannerID)
+	if err != nil {
+		if !errors.Is(err, models.BannerNotFound) {
+			r.log.Errorf("%s Failed to lock banner: %s", op, err)
+		}
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	banner := before
+	if err := patch.Apply(&banner); err != nil {
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	if check != nil {
+		if err := check(before, banner); err != nil {
+			return models.Banner{}, models.Banner{}, err
+		}
+	}
+
+	if !before.MatchesVersion(ifMatch) {
+		return models.Banner{}, models.Banner{}, models.VersionMismatch
+	}
+
 	bannerDB := mapOnDBBanner(banner)
-	res, err := tx.ExecContext(ctx, `UPDATE banners SET tag_ids=$1, feature_id=$2, content=$3, is_active=$4 WHERE id=$5`,
-		pq.Array(bannerDB.TagIDs), bannerDB.FeatureID, bannerDB.Content, bannerDB.IsActive, bannerDB.ID)
+	row := tx.QueryRowContext(ctx, `UPDATE banners SET tag_ids=$1, feature_id=$2, content=$3, localized_content=$4, is_active=$5, active_from=$6, active_until=$7, version=version+1 WHERE id=$8
+RETURNING `+bannerColumns,
+		pq.Array(bannerDB.TagIDs), bannerDB.FeatureID, bannerDB.Content, bannerDB.Localized, bannerDB.IsActive, bannerDB.ActiveFrom, bannerDB.ActiveUntil, bannerDB.ID)
+	afterDB, err := scanBanner(row)
 	if err != nil {
 		r.log.Errorf("%s Failed to execute query: %s", op, err)
-		return false, err
+		return models.Banner{}, models.Banner{}, err
 	}
 
-	affectedRows, err := res.RowsAffected()
-	if err != nil {
-		r.log.Errorf("%s Failed to get rows affected: %s", op, err)
-		return false, err
+	after = mapOnBanner(afterDB)
+	if err := r.replaceBannerTags(ctx, tx, after.ID, after.FeatureID, after.TagIDs); err != nil {
+		r.log.Errorf("%s Failed to save banner tags: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	if err := r.saveRevision(ctx, tx, bannerID, author); err != nil {
+		r.log.Errorf("%s Failed to save revision: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
 	}
 
-	return affectedRows != 0, nil
+	if err := tx.Commit(); err != nil {
+		r.log.Errorf("%s Failed to commit transaction: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	return before, after, nil
 }
 
-func (r *repository) CreateBanner(ctx context.Context, banner models.Banner) (int, error) {
+func (r *repository) CreateBanner(ctx context.Context, banner models.Banner, author string) (int, error) {
 	const op = "repository.CreateBanner"
 
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -207,50 +228,276 @@ func (r *repository) CreateBanner(ctx context.Context, banner models.Banner) (in
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO banners (tag_ids, feature_id, content, is_active) values ($1, $2, $3, $4) RETURNING id`)
+	id, err := r.insertBanner(ctx, tx, banner, author)
 	if err != nil {
-		r.log.Errorf("%s Failed to prepare query: %s", op, err)
+		r.log.Errorf("%s Failed to insert banner: %s", op, err)
 		return 0, err
 	}
-	defer stmt.Close()
 
-	var id int
-	bannerDB := mapOnDBBanner(banner)
-	err = stmt.QueryRowContext(ctx, pq.Array(bannerDB.TagIDs), bannerDB.FeatureID, bannerDB.Content, bannerDB.IsActive).Scan(&id)
-	if err != nil {
-		r.log.Errorf("%s Failed to get last insert ID: %s", op, err)
+	if err := tx.Commit(); err != nil {
+		r.log.Errorf("%s Failed to commit transaction: %s", op, err)
 		return 0, err
 	}
 
+	return id, nil
+}
+
+// CreateBanners inserts all banners in one transaction. Banners clashing on
+// (tag_id, feature_id) are collected into *models.BulkImportError and nothing
+// is saved then. A clash with an earlier banner of the same import is a
+// *models.BulkDuplicateError, its id would not survive the rollback.
+func (r *repository) CreateBanners(ctx context.Context, banners []models.Banner, author string) ([]int, error) {
+	const op = "repository.CreateBanners"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Errorf("%s Failed to begin transaction: %s", op, err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, 0, len(banners))
+	indexes := make(map[int]int, len(banners))
+	var rejected []*models.BulkItemError
+	for i, banner := range banners {
+		id, err := r.insertBanner(ctx, tx, banner, author)
+		var conflict *models.BannerConflictError
+		if errors.As(err, &conflict) {
+			if index, ok := indexes[conflict.BannerID]; ok {
+				err = &models.BulkDuplicateError{Index: index}
+			}
+			rejected = append(rejected, &models.BulkItemError{Index: i, Err: err})
+			continue
+		}
+		if err != nil {
+			r.log.Errorf("%s Failed to insert banner %d: %s", op, i, err)
+			return nil, err
+		}
+
+		ids = append(ids, id)
+		indexes[id] = i
+	}
+
+	if len(rejected) > 0 {
+		return nil, &models.BulkImportError{Items: rejected}
+	}
+
 	if err := tx.Commit(); err != nil {
 		r.log.Errorf("%s Failed to commit transaction: %s", op, err)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// insertBanner inserts the banner with its tags and first revision. A tag
+// conflict is returned as *models.BannerConflictError without aborting tx.
+func (r *repository) insertBanner(ctx context.Context, tx *sql.Tx, banner models.Banner, author string) (int, error) {
+	var id int
+	bannerDB := mapOnDBBanner(banner)
+	err := tx.QueryRowContext(ctx, `INSERT INTO banners (tag_ids, feature_id, content, localized_content, is_active, active_from, active_until) values ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		pq.Array(bannerDB.TagIDs), bannerDB.FeatureID, bannerDB.Content, bannerDB.Localized, bannerDB.IsActive, bannerDB.ActiveFrom, bannerDB.ActiveUntil).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("insert banner: %w", err)
+	}
+
+	if err := r.replaceBannerTags(ctx, tx, id, banner.FeatureID, banner.TagIDs); err != nil {
 		return 0, err
 	}
 
+	if err := r.saveRevision(ctx, tx, id, author); err != nil {
+		return 0, fmt.Errorf("save revision: %w", err)
+	}
+
 	return id, nil
 }
 
-func (r *repository) DeleteBanner(ctx context.Context, bannerID int) (bool, error) {
+// ExportBanners calls fn for every banner in id order while reading them from
+// the database, so the whole table is never held in memory.
+func (r *repository) ExportBanners(ctx context.Context, fn func(models.Banner) error) error {
+	const op = "repository.ExportBanners"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+bannerColumns+` FROM banners WHERE deleted_at IS NULL ORDER BY id`)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		bannerDB, err := scanBanner(rows)
+		if err != nil {
+			r.log.Errorf("%s Failed to scan row: %s", op, err)
+			return err
+		}
+
+		if err := fn(mapOnBanner(bannerDB)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.Errorf("%s Failed to iterate rows: %s", op, err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteBanner marks the banner deleted and releases its (tag_id, feature_id)
+// pairs, the row itself is removed by PurgeDeletedBanners. With non-nil
+// ifMatch the banner version must be one of them. A non-nil check may refuse
+// the locked banner.
+func (r *repository) DeleteBanner(ctx context.Context, bannerID int, ifMatch []int, check func(models.Banner) error) (models.Banner, error) {
 	const op = "repository.DeleteBanner"
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		r.log.Errorf("%s Failed to begin transaction: %s", op, err)
-		return false, err
+		return models.Banner{}, err
 	}
 	defer tx.Rollback()
 
-	res, err := r.db.ExecContext(ctx, `DELETE FROM banners WHERE id=$1`, bannerID)
+	banner, err := r.lockBanner(ctx, tx, bannerID)
 	if err != nil {
+		if !errors.Is(err, models.BannerNotFound) {
+			r.log.Errorf("%s Failed to lock banner: %s", op, err)
+		}
+		return models.Banner{}, err
+	}
+
+	if check != nil {
+		if err := check(banner); err != nil {
+			return models.Banner{}, err
+		}
+	}
+
+	if !banner.MatchesVersion(ifMatch) {
+		return models.Banner{}, models.VersionMismatch
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE banners SET deleted_at=now(), version=version+1 WHERE id=$1`, bannerID); err != nil {
 		r.log.Errorf("%s Failed to execute query: %s", op, err)
-		return false, err
+		return models.Banner{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM banner_tags WHERE banner_id=$1`, bannerID); err != nil {
+		r.log.Errorf("%s Failed to release banner tags: %s", op, err)
+		return models.Banner{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Errorf("%s Failed to commit transaction: %s", op, err)
+		return models.Banner{}, err
 	}
 
-	affected, err := res.RowsAffected()
+	return banner, nil
+}
+
+// RestoreBanner brings back a deleted banner. It fails with
+// *models.BannerConflictError if its pairs were taken in the meantime. A
+// non-nil check may reject the restored banner, it stays deleted then.
+func (r *repository) RestoreBanner(ctx context.Context, bannerID int, check func(models.Banner) error) (models.Banner, error) {
+	const op = "repository.RestoreBanner"
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		r.log.Errorf("%s Failed to get rows affected: %s", op, err)
-		return false, err
+		r.log.Errorf("%s Failed to begin transaction: %s", op, err)
+		return models.Banner{}, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `UPDATE banners SET deleted_at=NULL, version=version+1 WHERE id=$1 AND deleted_at IS NOT NULL
+RETURNING `+bannerColumns, bannerID)
+	bannerDB, err := scanBanner(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Banner{}, models.BannerNotFound
+		}
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return models.Banner{}, err
+	}
+
+	banner := mapOnBanner(bannerDB)
+	if check != nil {
+		if err := check(banner); err != nil {
+			return models.Banner{}, err
+		}
+	}
+
+	if err := r.replaceBannerTags(ctx, tx, banner.ID, banner.FeatureID, banner.TagIDs); err != nil {
+		r.log.Errorf("%s Failed to save banner tags: %s", op, err)
+		return models.Banner{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Errorf("%s Failed to commit transaction: %s", op, err)
+		return models.Banner{}, err
+	}
+
+	return banner, nil
+}
+
+// PurgeDeletedBanners removes banners deleted before the given time and
+// returns how many were removed. Their revisions go with them on purpose
+// (banner_revisions cascades on delete): history is kept only as long as the
+// banner can still be restored, after BANNER_RETENTION it is gone for good.
+func (r *repository) PurgeDeletedBanners(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	const op = "repository.PurgeDeletedBanners"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM banners WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// GetBannerByID returns the banner regardless of its tags together with its
+// latest revision.
+func (r *repository) GetBannerByID(ctx context.Context, bannerID int) (models.BannerDetails, error) {
+	const op = "repository.GetBannerByID"
+
+	var (
+		revision     sql.NullInt64
+		revisionBy   sql.NullString
+		revisionTime sql.NullTime
+	)
+	row := r.db.QueryRowContext(ctx, `SELECT `+bannerColumns+`, br.revision, br.author, br.revision_created_at
+FROM banners b LEFT JOIN LATERAL (
+	SELECT version AS revision, author, created_at AS revision_created_at
+	FROM banner_revisions WHERE banner_id=b.id ORDER BY version DESC LIMIT 1
+) br ON TRUE
+WHERE b.id=$1 AND b.deleted_at IS NULL`, bannerID)
+	bannerDB, err := scanBanner(row, &revision, &revisionBy, &revisionTime)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.BannerDetails{}, models.BannerNotFound
+		}
+		r.log.Errorf("%s Failed to scan row: %s", op, err)
+		return models.BannerDetails{}, err
+	}
+
+	return models.BannerDetails{
+		Banner:       mapOnBanner(bannerDB),
+		Revision:     int(revision.Int64),
+		RevisionBy:   revisionBy.String,
+		RevisionTime: fromNullTime(revisionTime),
+	}, nil
+}
+
+// lockBanner reads the banner and locks its row until the end of the transaction.
+func (r *repository) lockBanner(ctx context.Context, tx *sql.Tx, bannerID int) (models.Banner, error) {
+	row := tx.QueryRowContext(ctx, `SELECT `+bannerColumns+`
+FROM banners WHERE id=$1 AND deleted_at IS NULL FOR UPDATE`, bannerID)
+	bannerDB, err := scanBanner(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Banner{}, models.BannerNotFound
+		}
+		return models.Banner{}, err
 	}
 
-	return affected != 0, nil
+	return mapOnBanner(bannerDB), nil
 }
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"project/internal/app/models"
+	"testing"
+)
+
+func TestRepository_CreateBannersDuplicate(t *testing.T) {
+	r := testRepository(t)
+	ctx := context.Background()
+	if err := r.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := r.CreateBanners(ctx, []models.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: map[string]any{}},
+		{TagIDs: []int{3}, FeatureID: 1, Content: map[string]any{}},
+		{TagIDs: []int{2}, FeatureID: 1, Content: map[string]any{}},
+	}, "test")
+
+	var bulkErr *models.BulkImportError
+	if !errors.As(err, &bulkErr) || len(bulkErr.Items) != 1 {
+		t.Fatalf("expected a single rejected banner, got %v", err)
+	}
+	var duplicate *models.BulkDuplicateError
+	if item := bulkErr.Items[0]; item.Index != 2 || !errors.As(item.Err, &duplicate) || duplicate.Index != 0 {
+		t.Errorf("expected banner 2 to clash with banner 0 of the import, got %v", item)
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"project/internal/app/models"
+)
+
+// GetBannerRevisions returns the revisions of the banner, newest first. A
+// deleted banner has no visible history, it is models.BannerNotFound until
+// restored.
+func (r *repository) GetBannerRevisions(ctx context.Context, bannerID int) ([]models.BannerRevision, error) {
+	const op = "repository.GetBannerRevisions"
+
+	rows, err := r.db.QueryContext(ctx, `SELECT br.banner_id, br.version, br.tag_ids, br.feature_id, br.content, br.localized_content, br.is_active, br.active_from, br.active_until, br.author, br.created_at
+FROM banner_revisions br JOIN banners b ON b.id=br.banner_id
+WHERE br.banner_id=$1 AND b.deleted_at IS NULL ORDER BY br.version DESC`, bannerID)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]models.BannerRevision, 0)
+	for rows.Next() {
+		var revisionDB dbBannerRevision
+		err := rows.Scan(
+			&revisionDB.BannerID,
+			&revisionDB.Version,
+			pq.Array(&revisionDB.TagIDs),
+			&revisionDB.FeatureID,
+			&revisionDB.Content,
+			&revisionDB.Localized,
+			&revisionDB.IsActive,
+			&revisionDB.ActiveFrom,
+			&revisionDB.ActiveUntil,
+			&revisionDB.Author,
+			&revisionDB.CreatedAt,
+		)
+		if err != nil {
+			r.log.Errorf("%s Failed to scan row: %s", op, err)
+			return nil, err
+		}
+
+		revisions = append(revisions, mapOnBannerRevision(revisionDB))
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.Errorf("%s Failed to iterate rows: %s", op, err)
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, models.BannerNotFound
+	}
+
+	return revisions, nil
+}
+
+// RestoreBannerRevision copies the revision back into the banner and records it
+// as a new revision. It returns the banner state before and after the restore.
+// A non-nil check may reject the restored banner, nothing is saved then.
+func (r *repository) RestoreBannerRevision(ctx context.Context, bannerID int, version int, check func(models.Banner) error, author string) (before models.Banner, after models.Banner, err error) {
+	const op = "repository.RestoreBannerRevision"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.log.Errorf("%s Failed to begin transaction: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
+	}
+	defer tx.Rollback()
+
+	before, err = r.lockBanner(ctx, tx, bannerID)
+	if err != nil {
+		if !errors.Is(err, models.BannerNotFound) {
+			r.log.Errorf("%s Failed to lock banner: %s", op, err)
+		}
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	row := tx.QueryRowContext(ctx, `UPDATE banners b SET tag_ids=br.tag_ids, feature_id=br.feature_id, content=br.content,
+localized_content=COALESCE(br.localized_content, '{}'), is_active=br.is_active,
+active_from=br.active_from, active_until=br.active_until, version=b.version+1
+FROM banner_revisions br WHERE b.id=br.banner_id AND br.banner_id=$1 AND br.version=$2
+RETURNING b.id, b.tag_ids, b.feature_id, b.content, b.localized_content, b.is_active, b.active_from, b.active_until, b.version, b.created_at, b.updated_at`, bannerID, version)
+	afterDB, err := scanBanner(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Banner{}, models.Banner{}, models.RevisionNotFound
+		}
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	after = mapOnBanner(afterDB)
+	if check != nil {
+		if err := check(after); err != nil {
+			return models.Banner{}, models.Banner{}, err
+		}
+	}
+
+	if err := r.replaceBannerTags(ctx, tx, after.ID, after.FeatureID, after.TagIDs); err != nil {
+		r.log.Errorf("%s Failed to save banner tags: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	if err := r.saveRevision(ctx, tx, bannerID, author); err != nil {
+		r.log.Errorf("%s Failed to save revision: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.log.Errorf("%s Failed to commit transaction: %s", op, err)
+		return models.Banner{}, models.Banner{}, err
+	}
+
+	return before, after, nil
+}
+
+// saveRevision snapshots the current state of the banner as its next revision
+// and drops revisions that fall out of the configured history size. The next
+// version is MAX(version)+1, so the banner row is locked first and concurrent
+// writers of the same banner number their revisions one after another.
+func (r *repository) saveRevision(ctx context.Context, tx *sql.Tx, bannerID int, author string) error {
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM banners WHERE id=$1 FOR UPDATE`, bannerID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO banner_revisions (banner_id, version, tag_ids, feature_id, content, localized_content, is_active, active_from, active_until, author)
+SELECT id, COALESCE((SELECT MAX(version) FROM banner_revisions WHERE banner_id=$1), 0) + 1, tag_ids, feature_id, content, localized_content, is_active, active_from, active_until, $2
+FROM banners WHERE id=$1`, bannerID, author)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if inserted == 0 {
+		return errors.New("banner row is missing")
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM banner_revisions
+WHERE banner_id=$1 AND version <= (SELECT MAX(version) FROM banner_revisions WHERE banner_id=$1) - $2`, bannerID, r.historySize)
+	return err
+}
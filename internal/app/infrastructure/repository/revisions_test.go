@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"project/internal/app/models"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRepository_UpdateBannerConcurrent(t *testing.T) {
+	r := testRepository(t)
+	ctx := context.Background()
+	if err := r.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := r.CreateBanner(ctx, models.Banner{TagIDs: []int{1}, FeatureID: 1, Content: map[string]any{}}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			active := i%2 == 0
+			_, _, errs[i] = r.UpdateBanner(ctx, id, models.BannerPatch{IsActive: &active}, nil, nil, "test")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("expected concurrent updates to save a revision each, got %v", err)
+		}
+	}
+
+	revisions, err := r.GetBannerRevisions(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := make([]int, len(revisions))
+	for i, revision := range revisions {
+		versions[i] = revision.Version
+	}
+	if want := []int{6, 5, 4}; !slices.Equal(versions, want) {
+		t.Errorf("expected revisions %v, got %v", want, versions)
+	}
+}
+
+func TestRepository_GetBannerRevisionsDeleted(t *testing.T) {
+	r := testRepository(t)
+	ctx := context.Background()
+	if err := r.MigrateUp(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := r.CreateBanner(ctx, models.Banner{TagIDs: []int{1}, FeatureID: 1, Content: map[string]any{}}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.DeleteBanner(ctx, id, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.GetBannerRevisions(ctx, id); !errors.Is(err, models.BannerNotFound) {
+		t.Errorf("expected no history for a deleted banner, got %v", err)
+	}
+
+	if _, err := r.RestoreBanner(ctx, id, nil); err != nil {
+		t.Fatal(err)
+	}
+	if revisions, err := r.GetBannerRevisions(ctx, id); err != nil || len(revisions) != 1 {
+		t.Errorf("expected the history back after a restore, got %d revisions, %v", len(revisions), err)
+	}
+}
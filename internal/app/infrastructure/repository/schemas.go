@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"project/internal/app/models"
+)
+
+func (r *repository) GetFeatureSchema(ctx context.Context, featureID int) (models.FeatureSchema, error) {
+	const op = "repository.GetFeatureSchema"
+
+	var raw []byte
+	schema := models.FeatureSchema{FeatureID: featureID}
+	err := r.db.QueryRowContext(ctx, `SELECT schema, updated_at FROM feature_schemas WHERE feature_id=$1`, featureID).
+		Scan(&raw, &schema.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.FeatureSchema{}, models.SchemaNotFound
+		}
+		r.log.Errorf("%s Failed to scan row: %s", op, err)
+		return models.FeatureSchema{}, err
+	}
+
+	schema.Schema = raw
+	return schema, nil
+}
+
+// SaveFeatureSchema creates or replaces the schema of the feature.
+func (r *repository) SaveFeatureSchema(ctx context.Context, featureID int, schema []byte) (models.FeatureSchema, error) {
+	const op = "repository.SaveFeatureSchema"
+
+	var raw []byte
+	saved := models.FeatureSchema{FeatureID: featureID}
+	err := r.db.QueryRowContext(ctx, `INSERT INTO feature_schemas (feature_id, schema) VALUES ($1, $2)
+ON CONFLICT (feature_id) DO UPDATE SET schema=EXCLUDED.schema, updated_at=now()
+RETURNING schema, updated_at`, featureID, string(schema)).Scan(&raw, &saved.UpdatedAt)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return models.FeatureSchema{}, err
+	}
+
+	saved.Schema = raw
+	return saved, nil
+}
+
+func (r *repository) DeleteFeatureSchema(ctx context.Context, featureID int) error {
+	const op = "repository.DeleteFeatureSchema"
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM feature_schemas WHERE feature_id=$1`, featureID)
+	if err != nil {
+		r.log.Errorf("%s Failed to execute query: %s", op, err)
+		return err
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		r.log.Errorf("%s Failed to get affected rows: %s", op, err)
+		return err
+	}
+	if deleted == 0 {
+		return models.SchemaNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"project/internal/app/models"
+	"slices"
+)
+
+// replaceBannerTags makes banner_tags mirror the banner's tag_ids and feature_id.
+// A (tag_id, feature_id) pair already taken by another banner is reported as
+// *models.BannerConflictError.
+func (r *repository) replaceBannerTags(ctx context.Context, tx *sql.Tx, bannerID int, featureID int, tagIDs []int) error {
+	tags := make([]int32, 0, len(tagIDs))
+	for _, tagID := range tagIDs {
+		if !slices.Contains(tags, int32(tagID)) {
+			tags = append(tags, int32(tagID))
+		}
+	}
+
+	if err := r.checkBannerTags(ctx, tx, bannerID, featureID, tags); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM banner_tags WHERE banner_id=$1`, bannerID); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO banner_tags (banner_id, tag_id, feature_id)
+SELECT $1, unnest($2::integer[]), $3 ON CONFLICT (tag_id, feature_id) DO NOTHING`, bannerID, pq.Array(tags), featureID)
+	if err != nil {
+		return err
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if int(inserted) != len(tags) {
+		// Lost a race with a concurrent write, report whoever got the pair first.
+		if err := r.checkBannerTags(ctx, tx, bannerID, featureID, tags); err != nil {
+			return err
+		}
+		return errors.New("banner tags were not saved")
+	}
+
+	return nil
+}
+
+func (r *repository) checkBannerTags(ctx context.Context, tx *sql.Tx, bannerID int, featureID int, tagIDs []int32) error {
+	var conflictID int
+	err := tx.QueryRowContext(ctx, `SELECT banner_id FROM banner_tags
+WHERE feature_id=$1 AND tag_id=ANY($2) AND banner_id<>$3 LIMIT 1`, featureID, pq.Array(tagIDs), bannerID).Scan(&conflictID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return &models.BannerConflictError{BannerID: conflictID}
+}
@@ -1,19 +1,51 @@
 package repository
 
 import (
+	"database/sql"
 	"encoding/json"
+	"github.com/lib/pq"
 	"project/internal/app/models"
 	"time"
 )
 
+const bannerColumns = `id, tag_ids, feature_id, content, localized_content, is_active, active_from, active_until, version, created_at, updated_at`
+
 type dbBanner struct {
-	ID        int       `db:"id"`
-	TagIDs    []int32   `db:"tag_ids"`
-	FeatureID int       `db:"feature_id"`
-	Content   []byte    `db:"content"`
-	IsActive  bool      `db:"is_active"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID          int          `db:"id"`
+	TagIDs      []int32      `db:"tag_ids"`
+	FeatureID   int          `db:"feature_id"`
+	Content     []byte       `db:"content"`
+	Localized   []byte       `db:"localized_content"`
+	IsActive    bool         `db:"is_active"`
+	ActiveFrom  sql.NullTime `db:"active_from"`
+	ActiveUntil sql.NullTime `db:"active_until"`
+	Version     int          `db:"version"`
+	CreatedAt   time.Time    `db:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at"`
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanBanner scans bannerColumns, extra receives the columns selected after them.
+func scanBanner(row rowScanner, extra ...any) (dbBanner, error) {
+	var bannerDB dbBanner
+	dest := []any{
+		&bannerDB.ID,
+		pq.Array(&bannerDB.TagIDs),
+		&bannerDB.FeatureID,
+		&bannerDB.Content,
+		&bannerDB.Localized,
+		&bannerDB.IsActive,
+		&bannerDB.ActiveFrom,
+		&bannerDB.ActiveUntil,
+		&bannerDB.Version,
+		&bannerDB.CreatedAt,
+		&bannerDB.UpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	return bannerDB, err
 }
 
 func mapOnDBBanner(banner models.Banner) dbBanner {
@@ -22,18 +54,26 @@ func mapOnDBBanner(banner models.Banner) dbBanner {
 		panic(err)
 	}
 
+	localized, err := marshalLocalized(banner.LocalizedContent)
+	if err != nil {
+		panic(err)
+	}
+
 	tagIDs := make([]int32, len(banner.TagIDs))
 	for i, tagID := range banner.TagIDs {
 		tagIDs[i] = int32(tagID)
 	}
 
 	return dbBanner{
-		ID:        banner.ID,
-		TagIDs:    tagIDs,
-		FeatureID: banner.FeatureID,
-		Content:   content,
-		IsActive:  banner.IsActive,
-		CreatedAt: banner.CreatedAt,
+		ID:          banner.ID,
+		TagIDs:      tagIDs,
+		FeatureID:   banner.FeatureID,
+		Content:     content,
+		Localized:   localized,
+		IsActive:    banner.IsActive,
+		ActiveFrom:  toNullTime(banner.ActiveFrom),
+		ActiveUntil: toNullTime(banner.ActiveUntil),
+		CreatedAt:   banner.CreatedAt,
 	}
 }
 
@@ -44,18 +84,127 @@ func mapOnBanner(bannerDB dbBanner) models.Banner {
 		panic(err)
 	}
 
+	localized, err := unmarshalLocalized(bannerDB.Localized)
+	if err != nil {
+		panic(err)
+	}
+
 	tagIDs := make([]int, len(bannerDB.TagIDs))
 	for i, tagID := range bannerDB.TagIDs {
 		tagIDs[i] = int(tagID)
 	}
 
 	return models.Banner{
-		ID:        bannerDB.ID,
-		TagIDs:    tagIDs,
-		FeatureID: bannerDB.FeatureID,
-		Content:   content,
-		IsActive:  bannerDB.IsActive,
-		CreatedAt: bannerDB.CreatedAt,
-		UpdatedAt: bannerDB.UpdatedAt,
+		ID:               bannerDB.ID,
+		TagIDs:           tagIDs,
+		FeatureID:        bannerDB.FeatureID,
+		Content:          content,
+		LocalizedContent: localized,
+		IsActive:         bannerDB.IsActive,
+		ActiveFrom:       fromNullTime(bannerDB.ActiveFrom),
+		ActiveUntil:      fromNullTime(bannerDB.ActiveUntil),
+		Version:          bannerDB.Version,
+		ETag:             models.VersionETag(bannerDB.Version),
+		CreatedAt:        bannerDB.CreatedAt,
+		UpdatedAt:        bannerDB.UpdatedAt,
+	}
+}
+
+type dbBannerRevision struct {
+	BannerID    int          `db:"banner_id"`
+	Version     int          `db:"version"`
+	TagIDs      []int32      `db:"tag_ids"`
+	FeatureID   int          `db:"feature_id"`
+	Content     []byte       `db:"content"`
+	Localized   []byte       `db:"localized_content"`
+	IsActive    bool         `db:"is_active"`
+	ActiveFrom  sql.NullTime `db:"active_from"`
+	ActiveUntil sql.NullTime `db:"active_until"`
+	Author      string       `db:"author"`
+	CreatedAt   time.Time    `db:"created_at"`
+}
+
+func mapOnBannerRevision(revisionDB dbBannerRevision) models.BannerRevision {
+	var content map[string]interface{}
+	err := json.Unmarshal(revisionDB.Content, &content)
+	if err != nil {
+		panic(err)
+	}
+
+	localized, err := unmarshalLocalized(revisionDB.Localized)
+	if err != nil {
+		panic(err)
+	}
+
+	tagIDs := make([]int, len(revisionDB.TagIDs))
+	for i, tagID := range revisionDB.TagIDs {
+		tagIDs[i] = int(tagID)
+	}
+
+	return models.BannerRevision{
+		BannerID:         revisionDB.BannerID,
+		Version:          revisionDB.Version,
+		TagIDs:           tagIDs,
+		FeatureID:        revisionDB.FeatureID,
+		Content:          content,
+		LocalizedContent: localized,
+		IsActive:         revisionDB.IsActive,
+		ActiveFrom:       fromNullTime(revisionDB.ActiveFrom),
+		ActiveUntil:      fromNullTime(revisionDB.ActiveUntil),
+		Author:           revisionDB.Author,
+		CreatedAt:        revisionDB.CreatedAt,
+	}
+}
+
+func toNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func fromNullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func toNullInt(i *int) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*i), Valid: true}
+}
+
+func fromNullInt(i sql.NullInt64) *int {
+	if !i.Valid {
+		return nil
+	}
+	v := int(i.Int64)
+	return &v
+}
+
+// marshalLocalized stores absent variants as an empty object, the column is not null.
+func marshalLocalized(localized map[string]map[string]any) ([]byte, error) {
+	if localized == nil {
+		return []byte(`{}`), nil
+	}
+	return json.Marshal(localized)
+}
+
+// unmarshalLocalized returns nil for no variants, revisions saved before
+// localization have NULL there.
+func unmarshalLocalized(data []byte) (map[string]map[string]any, error) {
+	var localized map[string]map[string]any
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(data, &localized); err != nil {
+		return nil, err
+	}
+	if len(localized) == 0 {
+		return nil, nil
 	}
+	return localized, nil
 }
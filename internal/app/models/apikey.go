@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var APIKeyNotFound = errors.New("api key not found")
+
+// APIKey lets a backend service call the API without a JWT. Only a hash of
+// the key is stored, the key itself is shown once by IssuedAPIKey.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Usable reports whether the key may authenticate at now.
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Principal is the caller authenticated by the key.
+func (k *APIKey) Principal() Principal {
+	return Principal{
+		Subject: "apikey:" + k.Name,
+		Service: true,
+		Scopes:  k.Scopes,
+	}
+}
+
+// ValidScope reports whether scope is a known scope, optionally narrowed to a
+// feature.
+func ValidScope(scope string) bool {
+	for _, known := range []string{ScopeBannersRead, ScopeBannersWrite, ScopeBannersDelete} {
+		if scope == known {
+			return true
+		}
+		if featureID, ok := strings.CutPrefix(scope, known+":"); ok {
+			id, err := strconv.Atoi(featureID)
+			return err == nil && id > 0
+		}
+	}
+	return false
+}
@@ -3,21 +3,113 @@ package models
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 )
 
 var BannerNotFound = errors.New("banner not found")
 
+// VersionMismatch is returned when a write is conditioned on banner versions
+// (If-Match) and the current version is not among them.
+var VersionMismatch = errors.New("banner version mismatch")
+
+// StaleBanner is returned by the cache when a banner is stored after its pair
+// has been invalidated.
+var StaleBanner = errors.New("banner invalidated while it was loaded")
+
+// BannerConflictError is returned when another banner already covers one of
+// the (tag_id, feature_id) pairs.
+type BannerConflictError struct {
+	BannerID int
+}
+
+func (e *BannerConflictError) Error() string {
+	return fmt.Sprintf("tag and feature pair is already used by banner %d", e.BannerID)
+}
+
 type Banner struct {
 	ID        int            `json:"banner_id"`
 	TagIDs    []int          `json:"tag_ids"`
 	FeatureID int            `json:"feature_id"`
 	Content   map[string]any `json:"content"`
-	IsActive  bool           `json:"is_active"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	// LocalizedContent holds content variants by locale, Content is the
+	// variant for the default locale.
+	LocalizedContent map[string]map[string]any `json:"localized_content,omitempty"`
+	// Locale is the locale Content is in, it is only set by Localize.
+	Locale      string     `json:"locale,omitempty"`
+	IsActive    bool       `json:"is_active"`
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	// Version is incremented on every write and backs the banner ETag.
+	Version   int       `json:"version"`
+	ETag      string    `json:"etag"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VersionETag formats the banner version as a strong ETag.
+func VersionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ParseETag returns the version behind a strong ETag made by VersionETag.
+func ParseETag(etag string) (int, error) {
+	unquoted, err := strconv.Unquote(etag)
+	if err != nil {
+		return 0, fmt.Errorf("invalid etag %s", etag)
+	}
+	return strconv.Atoi(unquoted)
+}
+
+// MatchesVersion reports whether the banner version is one of versions, nil matches any version.
+func (b *Banner) MatchesVersion(versions []int) bool {
+	return versions == nil || slices.Contains(versions, b.Version)
+}
+
+// ScheduleStatus tells where a banner is relative to its active_from/active_until window.
+type ScheduleStatus string
+
+const (
+	ScheduleAny       ScheduleStatus = ""
+	ScheduleScheduled ScheduleStatus = "scheduled"
+	ScheduleLive      ScheduleStatus = "live"
+	ScheduleExpired   ScheduleStatus = "expired"
+)
+
+func ParseScheduleStatus(s string) (ScheduleStatus, error) {
+	switch status := ScheduleStatus(s); status {
+	case ScheduleScheduled, ScheduleLive, ScheduleExpired:
+		return status, nil
+	}
+	return ScheduleAny, fmt.Errorf("unknown schedule status %q", s)
+}
+
+func (b *Banner) ScheduleStatus(now time.Time) ScheduleStatus {
+	if b.ActiveFrom != nil && now.Before(*b.ActiveFrom) {
+		return ScheduleScheduled
+	}
+	if b.ActiveUntil != nil && !now.Before(*b.ActiveUntil) {
+		return ScheduleExpired
+	}
+	return ScheduleLive
+}
+
+// BannerKey identifies the banner a user sees for a single tag and feature.
+type BannerKey struct {
+	TagID     int `json:"tag_id"`
+	FeatureID int `json:"feature_id"`
+}
+
+func (b *Banner) Keys() []BannerKey {
+	keys := make([]BannerKey, len(b.TagIDs))
+	for i, tagID := range b.TagIDs {
+		keys[i] = BannerKey{TagID: tagID, FeatureID: b.FeatureID}
+	}
+	return keys
 }
 
 func (b *Banner) TagIDsFeatureIDHash() string {
@@ -26,3 +118,11 @@ func (b *Banner) TagIDsFeatureIDHash() string {
 	hashHex := hex.EncodeToString(hash[:])
 	return hashHex
 }
+
+func (b Banner) MarshalBinary() ([]byte, error) {
+	return json.Marshal(b)
+}
+
+func (b *Banner) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, b)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+)
+
+// BulkItemError is the reason the banner at Index of a bulk import was rejected.
+type BulkItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("banner %d: %s", e.Index, e.Err)
+}
+
+func (e *BulkItemError) Unwrap() error {
+	return e.Err
+}
+
+// BulkDuplicateError means the banner shares a (tag_id, feature_id) pair with
+// the banner at Index of the same import.
+type BulkDuplicateError struct {
+	Index int
+}
+
+func (e *BulkDuplicateError) Error() string {
+	return fmt.Sprintf("tag and feature pair is already used by banner %d of the import", e.Index)
+}
+
+// BulkImportError is returned when some banners of a bulk import were rejected,
+// in which case none of them are saved.
+type BulkImportError struct {
+	Items []*BulkItemError
+}
+
+func (e *BulkImportError) Error() string {
+	return fmt.Sprintf("%d of the banners were rejected, first: %s", len(e.Items), e.Items[0])
+}
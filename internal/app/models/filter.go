@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// BannerFilter describes the admin banner list query. Nil fields are not filtered on.
+type BannerFilter struct {
+	TagID       *int
+	FeatureID   *int
+	IsActive    *bool
+	Schedule    ScheduleStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	// ContentKey keeps banners whose content has the top-level key.
+	ContentKey string
+
+	// Cursor switches the query to keyset pagination, Offset is ignored then.
+	Cursor *BannerCursor
+	Limit  int
+	Offset int
+}
+
+// BannerCursor points at a banner in the (created_at, id) order of the admin list.
+type BannerCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int       `json:"id"`
+	// Backward selects banners before the cursor instead of after it.
+	Backward bool `json:"backward,omitempty"`
+}
+
+func (c BannerCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeBannerCursor(s string) (BannerCursor, error) {
+	var c BannerCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+type BannerPage struct {
+	Items []Banner
+	Next  *BannerCursor
+	Prev  *BannerCursor
+}
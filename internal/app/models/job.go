@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var JobNotFound = errors.New("job not found")
+
+type JobStatus string
+
+const (
+	JobPending JobStatus = "pending"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// DeleteJob deletes every banner matching FeatureID and TagID in the
+// background. Total is the number of matching banners when the job was created.
+type DeleteJob struct {
+	ID        int       `json:"job_id"`
+	FeatureID *int      `json:"feature_id,omitempty"`
+	TagID     *int      `json:"tag_id,omitempty"`
+	Status    JobStatus `json:"status"`
+	Total     int       `json:"total"`
+	Processed int       `json:"processed"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Filter is the banner filter matching the banners the job deletes.
+func (j *DeleteJob) Filter() BannerFilter {
+	return BannerFilter{FeatureID: j.FeatureID, TagID: j.TagID}
+}
@@ -0,0 +1,43 @@
+package models
+
+import "strings"
+
+// Localize returns the banner as it is served in locale: Content is the
+// variant for locale if the banner has one and the default content otherwise.
+// Locale tells which of the two was used, the variants are dropped.
+func (b Banner) Localize(locale string, defaultLocale string) Banner {
+	localized := b
+	localized.LocalizedContent = nil
+	localized.Locale = defaultLocale
+	if content, ok := b.LocalizedContent[locale]; ok && locale != defaultLocale {
+		localized.Content = content
+		localized.Locale = locale
+	}
+	return localized
+}
+
+// NegotiateLocale returns the first of the preferred locales that is
+// supported, either exactly or by its base language ("en-US" matches "en").
+// defaultLocale is returned if none of them is.
+func NegotiateLocale(preferred []string, supported []string, defaultLocale string) string {
+	for _, locale := range preferred {
+		if match, ok := matchLocale(locale, supported); ok {
+			return match
+		}
+		if base, _, found := strings.Cut(locale, "-"); found {
+			if match, ok := matchLocale(base, supported); ok {
+				return match
+			}
+		}
+	}
+	return defaultLocale
+}
+
+func matchLocale(locale string, supported []string) (string, bool) {
+	for _, s := range supported {
+		if strings.EqualFold(locale, s) {
+			return s, true
+		}
+	}
+	return "", false
+}
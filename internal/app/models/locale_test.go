@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNegotiateLocale(t *testing.T) {
+	supported := []string{"ru", "en"}
+	tests := []struct {
+		preferred []string
+		want      string
+	}{
+		{preferred: []string{"EN"}, want: "en"},
+		{preferred: []string{"en-US", "ru"}, want: "en"},
+		{preferred: []string{"de", "ru-RU"}, want: "ru"},
+		{preferred: []string{"de"}, want: "ru"},
+		{preferred: nil, want: "ru"},
+	}
+
+	for _, tt := range tests {
+		if got := NegotiateLocale(tt.preferred, supported, "ru"); got != tt.want {
+			t.Errorf("NegotiateLocale(%v) = %s, want %s", tt.preferred, got, tt.want)
+		}
+	}
+}
+
+func TestBannerPatch_LocalizedContent(t *testing.T) {
+	b := Banner{LocalizedContent: map[string]map[string]any{
+		"en": {"title": "Hello", "url": "a"},
+		"kk": {"title": "Сәлем"},
+	}}
+	patch := BannerPatch{LocalizedContent: map[string]map[string]any{
+		"en": {"url": nil},
+		"kk": nil,
+	}}
+	if err := patch.Apply(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]map[string]any{"en": {"title": "Hello"}}
+	if !reflect.DeepEqual(b.LocalizedContent, want) {
+		t.Errorf("unexpected localized content after patch %v", b.LocalizedContent)
+	}
+
+	if localized := b.Localize("en", "ru"); localized.Locale != "en" || localized.LocalizedContent != nil {
+		t.Errorf("unexpected localized banner %+v", localized)
+	}
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var InvalidSchedule = errors.New("active_until must be after active_from")
+
+// BannerPatch is a partial banner update, only the set fields are changed.
+type BannerPatch struct {
+	TagIDs    *[]int
+	FeatureID *int
+	// Content is a JSON Merge Patch (RFC 7396) applied to the banner content.
+	Content map[string]any
+	// LocalizedContent patches content variants by locale the same way, a
+	// null variant removes the locale.
+	LocalizedContent map[string]map[string]any
+	IsActive         *bool
+
+	// ActiveFrom and ActiveUntil are applied when the matching Set flag is
+	// true, a nil value clears the bound.
+	SetActiveFrom  bool
+	ActiveFrom     *time.Time
+	SetActiveUntil bool
+	ActiveUntil    *time.Time
+}
+
+// Apply changes the banner in place and validates the resulting schedule.
+func (p BannerPatch) Apply(b *Banner) error {
+	if p.TagIDs != nil {
+		b.TagIDs = *p.TagIDs
+	}
+	if p.FeatureID != nil {
+		b.FeatureID = *p.FeatureID
+	}
+	if p.Content != nil {
+		b.Content = MergePatch(b.Content, p.Content)
+	}
+	if p.LocalizedContent != nil {
+		b.LocalizedContent = patchLocalized(b.LocalizedContent, p.LocalizedContent)
+	}
+	if p.IsActive != nil {
+		b.IsActive = *p.IsActive
+	}
+	if p.SetActiveFrom {
+		b.ActiveFrom = p.ActiveFrom
+	}
+	if p.SetActiveUntil {
+		b.ActiveUntil = p.ActiveUntil
+	}
+
+	return ValidateSchedule(b.ActiveFrom, b.ActiveUntil)
+}
+
+func ValidateSchedule(activeFrom *time.Time, activeUntil *time.Time) error {
+	if activeFrom != nil && activeUntil != nil && !activeUntil.After(*activeFrom) {
+		return InvalidSchedule
+	}
+	return nil
+}
+
+func patchLocalized(target map[string]map[string]any, patch map[string]map[string]any) map[string]map[string]any {
+	result := make(map[string]map[string]any, len(target)+len(patch))
+	for locale, content := range target {
+		result[locale] = content
+	}
+
+	for locale, content := range patch {
+		if content == nil {
+			delete(result, locale)
+			continue
+		}
+		result[locale] = MergePatch(result[locale], content)
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// MergePatch applies an RFC 7396 merge patch to target: null members remove
+// keys, object members are merged recursively and anything else replaces the
+// value. target is not modified.
+func MergePatch(target map[string]any, patch map[string]any) map[string]any {
+	result := make(map[string]any, len(target)+len(patch))
+	for k, v := range target {
+		result[k] = v
+	}
+
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+
+		if patchObject, ok := v.(map[string]any); ok {
+			targetObject, _ := result[k].(map[string]any)
+			result[k] = MergePatch(targetObject, patchObject)
+			continue
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
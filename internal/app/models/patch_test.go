@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A.
+	cases := []struct {
+		target string
+		patch  string
+		result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, c := range cases {
+		var target, patch, expected map[string]any
+		mustUnmarshal(t, c.target, &target)
+		mustUnmarshal(t, c.patch, &patch)
+		mustUnmarshal(t, c.result, &expected)
+
+		if result := MergePatch(target, patch); !reflect.DeepEqual(result, expected) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %s", c.target, c.patch, result, c.result)
+		}
+	}
+}
+
+func TestBannerPatch_Apply(t *testing.T) {
+	featureID := 5
+	b := Banner{ID: 1, TagIDs: []int{1, 2}, FeatureID: 3, IsActive: true, Content: map[string]any{"title": "a", "url": "b"}}
+	err := BannerPatch{FeatureID: &featureID, Content: map[string]any{"url": nil}}.Apply(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.FeatureID != 5 || !b.IsActive || len(b.TagIDs) != 2 {
+		t.Errorf("unexpected banner after patch %+v", b)
+	}
+	if !reflect.DeepEqual(b.Content, map[string]any{"title": "a"}) {
+		t.Errorf("unexpected content after patch %v", b.Content)
+	}
+}
+
+func mustUnmarshal(t *testing.T, s string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var RevisionNotFound = errors.New("revision not found")
+
+type BannerRevision struct {
+	BannerID         int                       `json:"banner_id"`
+	Version          int                       `json:"version"`
+	TagIDs           []int                     `json:"tag_ids"`
+	FeatureID        int                       `json:"feature_id"`
+	Content          map[string]any            `json:"content"`
+	LocalizedContent map[string]map[string]any `json:"localized_content,omitempty"`
+	IsActive         bool                      `json:"is_active"`
+	ActiveFrom       *time.Time                `json:"active_from,omitempty"`
+	ActiveUntil      *time.Time                `json:"active_until,omitempty"`
+	Author           string                    `json:"author"`
+	CreatedAt        time.Time                 `json:"created_at"`
+}
+
+// BannerDetails is the admin view of a single banner. Revision is the number
+// of the latest saved revision, the one GET /banner/versions/:id lists first.
+type BannerDetails struct {
+	Banner
+	Revision     int        `json:"revision"`
+	RevisionBy   string     `json:"revision_author"`
+	RevisionTime *time.Time `json:"revision_created_at,omitempty"`
+}
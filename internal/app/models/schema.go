@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var SchemaNotFound = errors.New("feature schema not found")
+
+var InvalidSchema = errors.New("invalid json schema")
+
+// FeatureSchema is the JSON Schema every banner content of the feature must
+// conform to.
+type FeatureSchema struct {
+	FeatureID int             `json:"feature_id"`
+	Schema    json.RawMessage `json:"schema"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// ContentError is a single schema violation, Path is a JSON Pointer into the
+// content. Locale is set when the violation is in a localized variant.
+type ContentError struct {
+	Locale  string `json:"locale,omitempty"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ContentValidationError is returned when banner content does not conform to
+// the schema of its feature.
+type ContentValidationError struct {
+	FeatureID int
+	Errors    []ContentError
+}
+
+func (e *ContentValidationError) Error() string {
+	return fmt.Sprintf("content does not match schema of feature %d: %d errors", e.FeatureID, len(e.Errors))
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Scopes granted by tokens. A scope may be narrowed to a single feature by
+// suffixing the feature id, "banners:write:7" only allows editing banners of
+// feature 7.
+const (
+	ScopeBannersRead   = "banners:read"
+	ScopeBannersWrite  = "banners:write"
+	ScopeBannersDelete = "banners:delete"
+)
+
+// roleScopes are the scopes every role stands for, admin grants everything.
+var roleScopes = map[string][]string{
+	"viewer": {ScopeBannersRead},
+	"editor": {ScopeBannersRead, ScopeBannersWrite},
+}
+
+// RoleScopes returns the scopes the role stands for, none for unknown roles.
+func RoleScopes(role string) []string {
+	return slices.Clone(roleScopes[role])
+}
+
+// FeatureScopeError means the caller lacks Scope for the feature.
+type FeatureScopeError struct {
+	Scope     string
+	FeatureID int
+}
+
+func (e *FeatureScopeError) Error() string {
+	return fmt.Sprintf("%s scope is required for feature %d", e.Scope, e.FeatureID)
+}
+
+// AuthorizeBanner checks that the caller may apply scope to the banner and
+// move it to the other features. Callers that cannot read the banner get
+// BannerNotFound, so they cannot probe which ids exist in other features.
+func (p Principal) AuthorizeBanner(scope string, banner Banner, featureIDs ...int) error {
+	if !p.HasFeatureScope(ScopeBannersRead, banner.FeatureID) {
+		return BannerNotFound
+	}
+
+	for _, featureID := range append([]int{banner.FeatureID}, featureIDs...) {
+		if !p.HasFeatureScope(scope, featureID) {
+			return &FeatureScopeError{Scope: scope, FeatureID: featureID}
+		}
+	}
+	return nil
+}
+
+// FeatureScope narrows scope to the feature.
+func FeatureScope(scope string, featureID int) string {
+	return scope + ":" + strconv.Itoa(featureID)
+}
+
+// HasScope reports whether the caller holds scope for every feature.
+func (p Principal) HasScope(scope string) bool {
+	return p.Admin || slices.Contains(p.Scopes, scope)
+}
+
+// HasFeatureScope reports whether the caller holds scope for the feature.
+func (p Principal) HasFeatureScope(scope string, featureID int) bool {
+	return p.HasScope(scope) || slices.Contains(p.Scopes, FeatureScope(scope, featureID))
+}
+
+// HasAnyScope reports whether the caller holds scope for at least one feature.
+func (p Principal) HasAnyScope(scope string) bool {
+	return p.HasScope(scope) || slices.ContainsFunc(p.Scopes, func(s string) bool {
+		return strings.HasPrefix(s, scope+":")
+	})
+}
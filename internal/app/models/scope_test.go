@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrincipal_Scopes(t *testing.T) {
+	editor := Principal{Scopes: []string{ScopeBannersRead, FeatureScope(ScopeBannersWrite, 7)}}
+
+	if !editor.HasScope(ScopeBannersRead) || editor.HasScope(ScopeBannersWrite) {
+		t.Error("expected unscoped read and no unscoped write")
+	}
+	if !editor.HasFeatureScope(ScopeBannersWrite, 7) || editor.HasFeatureScope(ScopeBannersWrite, 8) {
+		t.Error("expected write for feature 7 only")
+	}
+	if !editor.HasAnyScope(ScopeBannersWrite) || editor.HasAnyScope(ScopeBannersDelete) {
+		t.Error("expected some write and no delete")
+	}
+
+	admin := Principal{Admin: true}
+	if !admin.HasFeatureScope(ScopeBannersDelete, 8) {
+		t.Error("expected admin to hold every scope")
+	}
+}
+
+func TestPrincipal_CanSeeTag(t *testing.T) {
+	user := Principal{User: User{ID: 1, TagIDs: []int{3, 5}}}
+	if !user.CanSeeTag(5) || user.CanSeeTag(4) {
+		t.Error("expected the user to see only tags 3 and 5")
+	}
+
+	viewer := Principal{User: User{ID: 2, TagIDs: []int{3}}, Scopes: []string{ScopeBannersRead}}
+	if viewer.CanSeeTag(4) {
+		t.Error("expected a user with banners:read to see only own tags")
+	}
+
+	service := Principal{Service: true, Scopes: []string{ScopeBannersRead}}
+	if !service.CanSeeTag(4) {
+		t.Error("expected a service with banners:read to see every tag")
+	}
+}
+
+func TestPrincipal_AuthorizeBanner(t *testing.T) {
+	editor := Principal{Scopes: []string{FeatureScope(ScopeBannersRead, 7), FeatureScope(ScopeBannersWrite, 7)}}
+
+	if err := editor.AuthorizeBanner(ScopeBannersWrite, Banner{FeatureID: 7}); err != nil {
+		t.Errorf("expected write to feature 7, got %v", err)
+	}
+	if err := editor.AuthorizeBanner(ScopeBannersWrite, Banner{FeatureID: 8}); !errors.Is(err, BannerNotFound) {
+		t.Errorf("expected banners of unreadable features to be hidden, got %v", err)
+	}
+
+	var forbidden *FeatureScopeError
+	if err := editor.AuthorizeBanner(ScopeBannersWrite, Banner{FeatureID: 7}, 8); !errors.As(err, &forbidden) || forbidden.FeatureID != 8 {
+		t.Errorf("expected moving to feature 8 to be forbidden, got %v", err)
+	}
+	if err := editor.AuthorizeBanner(ScopeBannersDelete, Banner{FeatureID: 7}); !errors.As(err, &forbidden) {
+		t.Errorf("expected delete to be forbidden, got %v", err)
+	}
+}
+
+func TestRoleScopes_Copy(t *testing.T) {
+	scopes := RoleScopes("viewer")
+	scopes[0] = ScopeBannersDelete
+	if RoleScopes("viewer")[0] != ScopeBannersRead {
+		t.Error("expected role scopes to be read-only")
+	}
+}
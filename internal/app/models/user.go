@@ -1,6 +1,26 @@
 package models
 
+import "slices"
+
 type User struct {
 	ID     uint64 `json:"id"`
 	TagIDs []int  `json:"tag_ids"`
 }
+
+// Principal is the authenticated caller as described by its token. Admin
+// holds every scope, see HasScope. Service is set for backend services
+// authenticated by an API key rather than a user token.
+type Principal struct {
+	User
+	Subject string   `json:"subject"`
+	Admin   bool     `json:"admin"`
+	Service bool     `json:"service,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+}
+
+// CanSeeTag reports whether the caller may read banners of the tag, users only
+// see the tags they belong to, whatever their scopes. Services holding
+// banners:read call on behalf of users and see every tag.
+func (p Principal) CanSeeTag(tagID int) bool {
+	return p.Admin || (p.Service && p.HasScope(ScopeBannersRead)) || slices.Contains(p.TagIDs, tagID)
+}
@@ -0,0 +1,102 @@
+package authservice
+
+import (
+	"context"
+	"project/internal/app/models"
+	"project/internal/logger"
+	"slices"
+	"testing"
+	"time"
+)
+
+type fakeKeyStorage struct {
+	keys    map[string]models.APIKey
+	touched []int
+}
+
+func (f *fakeKeyStorage) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (models.APIKey, error) {
+	key.ID = len(f.keys) + 1
+	f.keys[keyHash] = key
+	return key, nil
+}
+
+func (f *fakeKeyStorage) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	key, ok := f.keys[keyHash]
+	if !ok {
+		return models.APIKey{}, models.APIKeyNotFound
+	}
+	return key, nil
+}
+
+func (f *fakeKeyStorage) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return nil, nil
+}
+
+func (f *fakeKeyStorage) TouchAPIKey(ctx context.Context, id int) error {
+	f.touched = append(f.touched, id)
+	return nil
+}
+
+func (f *fakeKeyStorage) RevokeAPIKey(ctx context.Context, id int) error {
+	for hash, key := range f.keys {
+		if key.ID == id {
+			now := time.Now()
+			key.RevokedAt = &now
+			f.keys[hash] = key
+			return nil
+		}
+	}
+	return models.APIKeyNotFound
+}
+
+func TestAuthService_APIKeys(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	storage := &fakeKeyStorage{keys: map[string]models.APIKey{}}
+	as, err := New(logger.New(), storage, newFakeRevocations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	issued, err := as.IssueAPIKey(ctx, "recommendations", []string{models.ScopeBannersRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := storage.keys[issued.Key]; ok {
+		t.Error("expected the key to be stored hashed")
+	}
+
+	principal, err := as.AuthenticateAPIKey(ctx, issued.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.Subject != "apikey:recommendations" || !principal.HasScope(models.ScopeBannersRead) || principal.Admin {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+	if !principal.Service || !principal.CanSeeTag(4) {
+		t.Errorf("expected the service to see every tag, got %+v", principal)
+	}
+	if !slices.Equal(storage.touched, []int{issued.ID}) {
+		t.Errorf("expected the use to be recorded, got %v", storage.touched)
+	}
+
+	if _, err := as.AuthenticateAPIKey(ctx, issued.Key+"x"); err == nil {
+		t.Error("expected unknown key to be rejected")
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	old, err := as.IssueAPIKey(ctx, "old", []string{models.ScopeBannersRead}, &expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := as.AuthenticateAPIKey(ctx, old.Key); err == nil {
+		t.Error("expected expired key to be rejected")
+	}
+
+	if err := as.RevokeAPIKey(ctx, issued.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := as.AuthenticateAPIKey(ctx, issued.Key); err == nil {
+		t.Error("expected revoked key to be rejected")
+	}
+}
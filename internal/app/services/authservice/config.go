@@ -0,0 +1,98 @@
+package authservice
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultJWKSRefresh = 15 * time.Minute
+	defaultJWKSTimeout = 10 * time.Second
+	defaultMaxTokenTTL = 24 * time.Hour
+)
+
+type authConfig struct {
+	secret []byte
+	// jwksURL and jwksFile are alternative sources of the identity provider
+	// public keys, at most one of them is set.
+	jwksURL     string
+	jwksFile    string
+	jwksRefresh time.Duration
+	jwksTimeout time.Duration
+	// algorithms are the only signing algorithms tokens may use.
+	algorithms []string
+	// maxTokenTTL is how long a jti revocation is kept when the token expiry
+	// is not known.
+	maxTokenTTL time.Duration
+}
+
+func loadConfig() (*authConfig, error) {
+	secret := os.Getenv("JWT_SECRET")
+	jwksURL := os.Getenv("JWKS_URL")
+	jwksFile := os.Getenv("JWKS_FILE")
+	if jwksURL != "" && jwksFile != "" {
+		return nil, errors.New("JWKS_URL and JWKS_FILE environment variables are mutually exclusive")
+	}
+	if secret == "" && jwksURL == "" && jwksFile == "" {
+		return nil, errors.New("JWT_SECRET, JWKS_URL or JWKS_FILE environment variable not set")
+	}
+
+	jwksRefresh := defaultJWKSRefresh
+	if r := os.Getenv("JWKS_REFRESH_INTERVAL"); r != "" {
+		var err error
+		jwksRefresh, err = time.ParseDuration(r)
+		if err != nil || jwksRefresh <= 0 {
+			return nil, errors.New("JWKS_REFRESH_INTERVAL environment variable not valid")
+		}
+	}
+
+	maxTokenTTL := defaultMaxTokenTTL
+	if t := os.Getenv("JWT_MAX_TTL"); t != "" {
+		var err error
+		maxTokenTTL, err = time.ParseDuration(t)
+		if err != nil || maxTokenTTL <= 0 {
+			return nil, errors.New("JWT_MAX_TTL environment variable not valid")
+		}
+	}
+
+	var algorithms []string
+	if a := os.Getenv("JWT_ALGORITHMS"); a != "" {
+		for _, alg := range strings.Split(a, ",") {
+			alg = strings.TrimSpace(alg)
+			if !supportedAlgorithm(alg) {
+				return nil, errors.New("JWT_ALGORITHMS environment variable not valid")
+			}
+			algorithms = append(algorithms, alg)
+		}
+	} else {
+		if secret != "" {
+			algorithms = append(algorithms, "HS256")
+		}
+		if jwksURL != "" || jwksFile != "" {
+			algorithms = append(algorithms, "RS256", "ES256")
+		}
+	}
+
+	return &authConfig{
+		secret:      []byte(secret),
+		jwksURL:     jwksURL,
+		jwksFile:    jwksFile,
+		jwksRefresh: jwksRefresh,
+		jwksTimeout: defaultJWKSTimeout,
+		algorithms:  algorithms,
+		maxTokenTTL: maxTokenTTL,
+	}, nil
+}
+
+func supportedAlgorithm(alg string) bool {
+	switch alg {
+	case "HS256", "HS384", "HS512",
+		"RS256", "RS384", "RS512",
+		"PS256", "PS384", "PS512",
+		"ES256", "ES384", "ES512":
+		return true
+	}
+	return false
+}
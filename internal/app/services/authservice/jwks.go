@@ -0,0 +1,285 @@
+package authservice
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"project/internal/logger"
+	"sync"
+	"time"
+)
+
+const (
+	// minKeysRefresh bounds how often a token with an unknown kid may make
+	// the key set reload, so forged kids cannot hammer the identity provider.
+	minKeysRefresh = time.Minute
+	maxJWKSSize    = 1 << 20
+)
+
+var errUnknownKey = errors.New("unknown signing key")
+
+// jwk is a JSON Web Key (RFC 7517), only the public members of RSA and EC keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type publicKey struct {
+	key crypto.PublicKey
+	// alg pins the key to a single algorithm if the JWK names one.
+	alg string
+}
+
+// keySet holds the identity provider public keys by kid.
+type keySet struct {
+	log   logger.Logger
+	fetch func(ctx context.Context) ([]byte, error)
+
+	mu   sync.RWMutex
+	keys map[string]publicKey
+	// attemptedAt is when the last refresh started, failed ones included, so
+	// an unreachable source is not asked again for every unknown kid.
+	attemptedAt time.Time
+	refreshes   singleflight.Group
+}
+
+func newKeySet(log logger.Logger, cfg *authConfig) *keySet {
+	fetch := fileJWKS(cfg.jwksFile)
+	if cfg.jwksURL != "" {
+		fetch = urlJWKS(&http.Client{Timeout: cfg.jwksTimeout}, cfg.jwksURL)
+	}
+
+	return &keySet{
+		log:   log,
+		fetch: fetch,
+		keys:  map[string]publicKey{},
+	}
+}
+
+// key returns the key for kid that may verify alg. A token without a kid is
+// only accepted while the set has a single key.
+func (k *keySet) key(ctx context.Context, kid string, alg string) (crypto.PublicKey, error) {
+	key, ok := k.lookup(kid)
+	if !ok && kid != "" && k.stale() {
+		if err := k.refresh(ctx); err != nil {
+			return nil, err
+		}
+		key, ok = k.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %q", errUnknownKey, kid)
+	}
+
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("key %q is not for %s", kid, alg)
+	}
+	return key.key, nil
+}
+
+func (k *keySet) lookup(kid string) (publicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" {
+		if len(k.keys) != 1 {
+			return publicKey{}, false
+		}
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *keySet) stale() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return time.Since(k.attemptedAt) >= minKeysRefresh
+}
+
+// refresh reloads the keys, concurrent refreshes share a single fetch. The
+// current keys are kept if the fetched set is unusable.
+func (k *keySet) refresh(ctx context.Context) error {
+	const op = "authservice.refreshKeys"
+	_, err, _ := k.refreshes.Do("", func() (any, error) {
+		k.mu.Lock()
+		k.attemptedAt = time.Now()
+		k.mu.Unlock()
+
+		data, err := k.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		keys, err := parseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+
+		k.mu.Lock()
+		k.keys = keys
+		k.mu.Unlock()
+		return nil, nil
+	})
+	if err != nil {
+		k.log.Errorf("%s Failed to refresh keys: %v", op, err)
+	}
+	return err
+}
+
+// run refreshes the keys once per interval until ctx is done.
+func (k *keySet) run(ctx context.Context, interval time.Duration, timeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		refreshCtx, cancel := context.WithTimeout(ctx, timeout)
+		_ = k.refresh(refreshCtx)
+		cancel()
+	}
+}
+
+func fileJWKS(path string) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}
+}
+
+func urlJWKS(client *http.Client, url string) func(ctx context.Context) ([]byte, error) {
+	return func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected jwks response status %s", resp.Status)
+		}
+
+		return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	}
+}
+
+// parseJWKS returns the signing keys of the set by kid. Encryption keys and
+// key types other than RSA and EC are skipped.
+func parseJWKS(data []byte) (map[string]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAKey(k)
+		case "EC":
+			key, err = parseECKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = publicKey{key: key, alg: k.Alg}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no signing keys")
+	}
+	return keys, nil
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid modulus")
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	exponent := int(new(big.Int).SetBytes(e).Int64())
+	if exponent < 3 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+}
+
+func parseECKey(k jwk) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	var checker ecdh.Curve
+	switch k.Crv {
+	case "P-256":
+		curve, checker = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, checker = elliptic.P384(), ecdh.P384()
+	case "P-521":
+		curve, checker = elliptic.P521(), ecdh.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil || len(x) != size {
+		return nil, errors.New("invalid x coordinate")
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil || len(y) != size {
+		return nil, errors.New("invalid y coordinate")
+	}
+
+	// ecdh rejects points that are not on the curve.
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := checker.NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
@@ -0,0 +1,150 @@
+package authservice
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"project/internal/logger"
+	"testing"
+	"time"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jwk {
+	return jwk{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func writeJWKS(t *testing.T, path string, keys ...jwk) {
+	data, err := json.Marshal(map[string][]jwk{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	token := jwt.NewWithClaims(method, claims{
+		Admin:          true,
+		StandardClaims: jwt.StandardClaims{Subject: "editor", ExpiresAt: time.Now().Add(time.Hour).Unix()},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAuthService_AuthenticateJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWKS_FILE", path)
+
+	as, err := New(logger.New(), nil, newFakeRevocations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	valid := map[string]string{
+		"RS256": signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey),
+		"ES256": signToken(t, jwt.SigningMethodES256, "ec-1", ecKey),
+	}
+	for name, token := range valid {
+		principal, err := as.Authenticate(ctx, token)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if !principal.Admin || principal.Subject != "editor" {
+			t.Errorf("%s: unexpected principal %+v", name, principal)
+		}
+	}
+
+	invalid := map[string]string{
+		"wrong kid":         signToken(t, jwt.SigningMethodRS256, "ec-1", rsaKey),
+		"unknown kid":       signToken(t, jwt.SigningMethodRS256, "rsa-2", rsaKey),
+		"no kid":            signToken(t, jwt.SigningMethodRS256, "", rsaKey),
+		"algorithm not set": signToken(t, jwt.SigningMethodRS512, "rsa-1", rsaKey),
+		"hmac":              signToken(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret")),
+	}
+	for name, token := range invalid {
+		if _, err := as.Authenticate(ctx, token); err == nil {
+			t.Errorf("%s: expected token to be rejected", name)
+		}
+	}
+
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeJWKS(t, path, rsaJWK("rsa-2", &rotated.PublicKey))
+	if err := as.keys.refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := as.Authenticate(ctx, signToken(t, jwt.SigningMethodRS256, "rsa-2", rotated)); err != nil {
+		t.Errorf("expected rotated key to be accepted, got %v", err)
+	}
+	if _, err := as.Authenticate(ctx, valid["RS256"]); err == nil {
+		t.Error("expected retired key to be rejected")
+	}
+}
+
+func TestKeySet_FailingSource(t *testing.T) {
+	fetches := 0
+	keys := &keySet{
+		log: logger.New(),
+		fetch: func(ctx context.Context) ([]byte, error) {
+			fetches++
+			return nil, errors.New("jwks unavailable")
+		},
+		keys: map[string]publicKey{},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := keys.key(context.Background(), "unknown", "RS256"); err == nil {
+			t.Fatal("expected an error for an unknown kid")
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("expected failed refreshes to be rate limited too, got %d fetches", fetches)
+	}
+}
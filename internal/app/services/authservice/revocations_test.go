@@ -0,0 +1,155 @@
+package authservice
+
+import (
+	"context"
+	"errors"
+	jwtgo "github.com/golang-jwt/jwt"
+	"project/internal/logger"
+	"testing"
+	"time"
+)
+
+type fakeRevocations struct {
+	tokens   map[string]time.Duration
+	subjects map[string]time.Time
+	err      error
+}
+
+func newFakeRevocations() *fakeRevocations {
+	return &fakeRevocations{tokens: map[string]time.Duration{}, subjects: map[string]time.Time{}}
+}
+
+func (f *fakeRevocations) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
+	f.tokens[jti] = ttl
+	return nil
+}
+
+func (f *fakeRevocations) RevokeSubject(ctx context.Context, subject string, before time.Time) error {
+	f.subjects[subject] = before
+	return nil
+}
+
+func (f *fakeRevocations) IsTokenRevoked(ctx context.Context, jti string, subject string, issuedAt time.Time) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if _, ok := f.tokens[jti]; ok && jti != "" {
+		return true, nil
+	}
+	before, ok := f.subjects[subject]
+	if !ok {
+		return false, nil
+	}
+	return issuedAt.Unix() <= before.Unix(), nil
+}
+
+// signHS256 signs c with the test secret, iat and exp default to a token
+// issued now for an hour.
+func signHS256(t *testing.T, c claims) string {
+	if c.IssuedAt == 0 {
+		c.IssuedAt = time.Now().Unix()
+	}
+	if c.ExpiresAt == 0 {
+		c.ExpiresAt = c.IssuedAt + int64(time.Hour/time.Second)
+	}
+
+	signed, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, c).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func subjectToken(t *testing.T, jti string, subject string, issuedAt time.Time) string {
+	return signHS256(t, claims{StandardClaims: jwtgo.StandardClaims{Id: jti, Subject: subject, IssuedAt: issuedAt.Unix()}})
+}
+
+func TestAuthService_Revocations(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	revocations := newFakeRevocations()
+	as, err := New(logger.New(), nil, revocations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	issuedAt := time.Now().Add(-time.Minute)
+	first := subjectToken(t, "token-1", "alice", issuedAt)
+	second := subjectToken(t, "token-2", "alice", issuedAt)
+
+	expired := time.Now().Add(-time.Second)
+	if err := as.RevokeToken(ctx, "token-0", &expired); err != nil {
+		t.Fatal(err)
+	}
+	if len(revocations.tokens) != 0 {
+		t.Error("expected expired token not to be stored")
+	}
+
+	if err := as.RevokeToken(ctx, "token-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if revocations.tokens["token-1"] != as.cfg.maxTokenTTL {
+		t.Errorf("expected revocation for max token ttl, got %s", revocations.tokens["token-1"])
+	}
+	if _, err := as.Authenticate(ctx, first); !errors.Is(err, errRevokedToken) {
+		t.Errorf("expected revoked token to be rejected, got %v", err)
+	}
+	if _, err := as.Authenticate(ctx, second); err != nil {
+		t.Errorf("expected other token to be accepted, got %v", err)
+	}
+
+	if err := as.RevokeSubject(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := as.Authenticate(ctx, second); !errors.Is(err, errRevokedToken) {
+		t.Errorf("expected token of revoked subject to be rejected, got %v", err)
+	}
+
+	// iat may not be in the future, so date the revocation back instead.
+	revocations.subjects["alice"] = issuedAt
+	if _, err := as.Authenticate(ctx, subjectToken(t, "token-3", "alice", time.Now())); err != nil {
+		t.Errorf("expected token issued after revocation to be accepted, got %v", err)
+	}
+}
+
+func TestAuthService_RevokeSubjectWithoutIAT(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	as, err := New(logger.New(), nil, newFakeRevocations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	signed, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, claims{
+		StandardClaims: jwtgo.StandardClaims{Subject: "bob", ExpiresAt: time.Now().Add(30 * 24 * time.Hour).Unix()},
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := as.Authenticate(ctx, signed); err != nil {
+		t.Fatalf("expected a token without iat to be accepted, got %v", err)
+	}
+
+	if err := as.RevokeSubject(ctx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := as.Authenticate(ctx, signed); !errors.Is(err, errRevokedToken) {
+		t.Errorf("expected a token without iat to count as issued before the revocation, got %v", err)
+	}
+}
+
+func TestAuthService_RevocationStoreDown(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	revocations := newFakeRevocations()
+	revocations.err = errors.New("connection refused")
+	as, err := New(logger.New(), nil, revocations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := signHS256(t, claims{Admin: true, StandardClaims: jwtgo.StandardClaims{Subject: "root"}})
+	if _, err := as.Authenticate(context.Background(), admin); !errors.Is(err, revocations.err) {
+		t.Errorf("expected admin token to be rejected while the store is down, got %v", err)
+	}
+}
@@ -3,44 +3,138 @@ package authservice
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt"
-	"os"
+	"project/internal/app/models"
 	"project/internal/logger"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 type authService struct {
-	log    logger.Logger
-	secret []byte
+	log logger.Logger
+	cfg *authConfig
+	// keys is nil unless a JWKS source is configured.
+	keys        *keySet
+	keyStorage  apiKeyStorage
+	revocations revocationStore
 }
 
 type claims struct {
 	Admin bool `json:"admin,omitempty"`
+	// Roles expand to scopes by models.RoleScopes, Scope is a space-separated
+	// list of scopes as in OAuth 2.0.
+	Roles []string `json:"roles,omitempty"`
+	Scope string   `json:"scope,omitempty"`
+	// UserID falls back to a numeric sub when it is not set.
+	UserID uint64 `json:"user_id,omitempty"`
+	TagIDs []int  `json:"tag_ids,omitempty"`
 	jwt.StandardClaims
 }
 
-func New(log logger.Logger) *authService {
-	s := os.Getenv("JWT_SECRET")
-	return &authService{
-		log:    log,
-		secret: []byte(s),
+func (c *claims) principal() models.Principal {
+	id := c.UserID
+	if id == 0 {
+		id, _ = strconv.ParseUint(c.Subject, 10, 64)
 	}
+
+	admin := c.Admin || slices.Contains(c.Roles, "admin")
+	scopes := strings.Fields(c.Scope)
+	for _, role := range c.Roles {
+		scopes = append(scopes, models.RoleScopes(role)...)
+	}
+	slices.Sort(scopes)
+
+	return models.Principal{
+		User:    models.User{ID: id, TagIDs: c.TagIDs},
+		Subject: c.Subject,
+		Admin:   admin,
+		Scopes:  slices.Compact(scopes),
+	}
+}
+
+// New loads the configuration and, if a JWKS source is set, the identity
+// provider keys. Failing to load them fails the start, no token could be
+// verified without them.
+func New(log logger.Logger, keyStorage apiKeyStorage, revocations revocationStore) (*authService, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Errorf("authservice.New Failed to load auth config: %s", err)
+		return nil, err
+	}
+
+	a := &authService{
+		log:         log,
+		cfg:         cfg,
+		keyStorage:  keyStorage,
+		revocations: revocations,
+	}
+
+	if cfg.jwksURL != "" || cfg.jwksFile != "" {
+		a.keys = newKeySet(log, cfg)
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.jwksTimeout)
+		defer cancel()
+		if err := a.keys.refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// RunKeyRefresher reloads the JWKS once per refresh interval until ctx is
+// done, so rotated keys are picked up. It returns at once without a JWKS.
+func (a *authService) RunKeyRefresher(ctx context.Context) {
+	if a.keys == nil {
+		return
+	}
+	a.keys.run(ctx, a.cfg.jwksRefresh, a.cfg.jwksTimeout)
 }
 
-func (a *authService) Authenticate(ctx context.Context, tokenString string) (admin bool, err error) {
+// Authenticate verifies the token and returns the caller it was issued to.
+// Revoked tokens are rejected.
+func (a *authService) Authenticate(ctx context.Context, tokenString string) (models.Principal, error) {
 	const op = "authservice.Authenticate"
 	var c claims
-	token, err := jwt.ParseWithClaims(tokenString, &c, func(token *jwt.Token) (interface{}, error) {
-		return a.secret, nil
+	parser := jwt.Parser{ValidMethods: a.cfg.algorithms}
+	token, err := parser.ParseWithClaims(tokenString, &c, func(token *jwt.Token) (interface{}, error) {
+		return a.verificationKey(ctx, token)
 	})
 
 	if err != nil {
 		a.log.Errorf("%s Failed to parse token: %v", op, err)
-		return false, err
+		return models.Principal{}, err
 	}
 
 	if !token.Valid {
-		return false, errors.New("invalid token")
+		return models.Principal{}, errors.New("invalid token")
+	}
+
+	if err := a.checkRevoked(ctx, &c); err != nil {
+		a.log.Errorf("%s Rejected token of %q: %v", op, c.Subject, err)
+		return models.Principal{}, err
+	}
+
+	return c.principal(), nil
+}
+
+// verificationKey returns the shared secret for HMAC tokens and the JWKS key
+// named by the kid header otherwise. The algorithm has already been checked
+// against the allowed ones by the parser.
+func (a *authService) verificationKey(ctx context.Context, token *jwt.Token) (interface{}, error) {
+	alg := token.Method.Alg()
+	if strings.HasPrefix(alg, "HS") {
+		if len(a.cfg.secret) == 0 {
+			return nil, fmt.Errorf("%s tokens are not accepted without JWT_SECRET", alg)
+		}
+		return a.cfg.secret, nil
+	}
+
+	if a.keys == nil {
+		return nil, fmt.Errorf("%s tokens are not accepted without JWKS", alg)
 	}
 
-	return c.Admin, nil
+	kid, _ := token.Header["kid"].(string)
+	return a.keys.key(ctx, kid, alg)
 }
@@ -0,0 +1,112 @@
+package bannerservice
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRefreshWindow  = time.Minute
+	defaultRefreshTimeout = 5 * time.Second
+	defaultRetention      = 30 * 24 * time.Hour
+	defaultPurgeInterval  = time.Hour
+	defaultDeleteBatch    = 100
+	defaultDeleteRetry    = time.Second
+	defaultJobPoll        = 10 * time.Second
+	defaultLocale         = "ru"
+	defaultLocales        = "ru,en"
+)
+
+type serviceConfig struct {
+	// refreshWindow is how long before expiry a cache hit reloads the banner
+	// in the background, it is positive and shorter than the cache ttl.
+	refreshWindow  time.Duration
+	refreshTimeout time.Duration
+	// retention is how long a deleted banner can still be restored, the
+	// purge removes its revision history as well.
+	retention     time.Duration
+	purgeInterval time.Duration
+	// deleteBatch is how many banners a delete job removes per transaction.
+	deleteBatch int
+	// deleteRetry is how long a delete job waits for banners locked by
+	// concurrent writes before trying them again.
+	deleteRetry time.Duration
+	jobPoll     time.Duration
+	// defaultLocale is the locale of the banner content itself, locales are
+	// the ones banners may have localized variants in.
+	defaultLocale string
+	locales       []string
+}
+
+func loadConfig() (*serviceConfig, error) {
+	refreshWindow := defaultRefreshWindow
+	if w := os.Getenv("CACHE_REFRESH_WINDOW"); w != "" {
+		var err error
+		refreshWindow, err = time.ParseDuration(w)
+		if err != nil || refreshWindow <= 0 {
+			return nil, errors.New("CACHE_REFRESH_WINDOW environment variable not valid")
+		}
+	}
+
+	retention := defaultRetention
+	if r := os.Getenv("BANNER_RETENTION"); r != "" {
+		var err error
+		retention, err = time.ParseDuration(r)
+		if err != nil || retention < 0 {
+			return nil, errors.New("BANNER_RETENTION environment variable not valid")
+		}
+	}
+
+	purgeInterval := defaultPurgeInterval
+	if i := os.Getenv("BANNER_PURGE_INTERVAL"); i != "" {
+		var err error
+		purgeInterval, err = time.ParseDuration(i)
+		if err != nil || purgeInterval <= 0 {
+			return nil, errors.New("BANNER_PURGE_INTERVAL environment variable not valid")
+		}
+	}
+
+	deleteBatch := defaultDeleteBatch
+	if b := os.Getenv("BANNER_DELETE_BATCH"); b != "" {
+		var err error
+		deleteBatch, err = strconv.Atoi(b)
+		if err != nil || deleteBatch <= 0 {
+			return nil, errors.New("BANNER_DELETE_BATCH environment variable not valid")
+		}
+	}
+
+	locale := defaultLocale
+	if l := os.Getenv("DEFAULT_LOCALE"); l != "" {
+		locale = l
+	}
+
+	localesEnv := defaultLocales
+	if l := os.Getenv("SUPPORTED_LOCALES"); l != "" {
+		localesEnv = l
+	}
+	var locales []string
+	for _, l := range strings.Split(localesEnv, ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			locales = append(locales, l)
+		}
+	}
+	if !slices.Contains(locales, locale) {
+		return nil, errors.New("SUPPORTED_LOCALES environment variable must include DEFAULT_LOCALE")
+	}
+
+	return &serviceConfig{
+		refreshWindow:  refreshWindow,
+		refreshTimeout: defaultRefreshTimeout,
+		retention:      retention,
+		purgeInterval:  purgeInterval,
+		deleteBatch:    deleteBatch,
+		deleteRetry:    defaultDeleteRetry,
+		jobPoll:        defaultJobPoll,
+		defaultLocale:  locale,
+		locales:        locales,
+	}, nil
+}
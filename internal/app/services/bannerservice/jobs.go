@@ -0,0 +1,132 @@
+package bannerservice
+
+import (
+	"context"
+	"errors"
+	"project/internal/app/models"
+	"time"
+)
+
+// EnqueueDeleteBanners creates a job deleting every banner with the feature
+// and/or tag, RunJobs picks it up.
+func (s *service) EnqueueDeleteBanners(ctx context.Context, featureID *int, tagID *int) (models.DeleteJob, error) {
+	const op = "bannerservice.EnqueueDeleteBanners"
+	job, err := s.storage.CreateDeleteJob(ctx, featureID, tagID)
+	if err != nil {
+		s.log.Errorf("%s Failed to create delete job: %v", op, err)
+		return models.DeleteJob{}, err
+	}
+
+	select {
+	case s.jobsWake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+func (s *service) GetDeleteJob(ctx context.Context, id int) (models.DeleteJob, error) {
+	const op = "bannerservice.GetDeleteJob"
+	job, err := s.storage.GetDeleteJob(ctx, id)
+	if err != nil {
+		if !errors.Is(err, models.JobNotFound) {
+			s.log.Errorf("%s Failed to get job %d: %v", op, id, err)
+		}
+		return models.DeleteJob{}, err
+	}
+
+	return job, nil
+}
+
+// RunJobs runs enqueued delete jobs one at a time until ctx is done. Jobs are
+// claimed in storage, so several instances can share the queue, and jobs
+// enqueued by another instance are noticed within the poll interval.
+func (s *service) RunJobs(ctx context.Context) {
+	const op = "bannerservice.RunJobs"
+	ticker := time.NewTicker(s.cfg.jobPoll)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			job, err := s.storage.ClaimDeleteJob(ctx)
+			if errors.Is(err, models.JobNotFound) {
+				break
+			}
+			if err != nil {
+				if ctx.Err() == nil {
+					s.log.Errorf("%s Failed to claim job: %v", op, err)
+				}
+				break
+			}
+
+			s.runDeleteJob(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.jobsWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDeleteJob deletes the job's banners batch by batch, invalidating their
+// cache keys and saving progress after every batch. Banners matching the job
+// may be created while it runs, Total grows with them so that Processed never
+// exceeds it.
+func (s *service) runDeleteJob(ctx context.Context, job models.DeleteJob) {
+	const op = "bannerservice.runDeleteJob"
+	for {
+		deleted, err := s.deleteBatch(ctx, job)
+		if err != nil {
+			if ctx.Err() != nil {
+				// Shutting down, the job is taken over once it goes stale.
+				return
+			}
+			s.log.Errorf("%s Failed to delete banners of job %d: %v", op, job.ID, err)
+			job.Status = models.JobFailed
+			job.Error = err.Error()
+			break
+		}
+
+		if len(deleted) == 0 {
+			job.Status = models.JobDone
+			break
+		}
+
+		s.invalidate(ctx, op, deleted...)
+		job.Processed += len(deleted)
+		job.Total = max(job.Total, job.Processed)
+		if err := s.storage.UpdateDeleteJob(ctx, job); err != nil {
+			s.log.Errorf("%s Failed to save progress of job %d: %v", op, job.ID, err)
+		}
+	}
+
+	if err := s.storage.UpdateDeleteJob(ctx, job); err != nil {
+		s.log.Errorf("%s Failed to save job %d: %v", op, job.ID, err)
+	}
+}
+
+// deleteBatch deletes the next batch of the job's banners. Batches skip
+// banners locked by concurrent writes, if only those are left it waits for
+// them, so an empty batch means no matching banner is left.
+func (s *service) deleteBatch(ctx context.Context, job models.DeleteJob) ([]models.Banner, error) {
+	for {
+		deleted, err := s.storage.DeleteBannersBatch(ctx, job.Filter(), s.cfg.deleteBatch)
+		if err != nil || len(deleted) > 0 {
+			return deleted, err
+		}
+
+		left, err := s.storage.HasBanners(ctx, job.Filter())
+		if err != nil || !left {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.cfg.deleteRetry):
+		}
+	}
+}
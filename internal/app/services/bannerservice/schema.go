@@ -0,0 +1,196 @@
+package bannerservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"io"
+	"project/internal/app/models"
+	"slices"
+)
+
+func (s *service) GetFeatureSchema(ctx context.Context, featureID int) (models.FeatureSchema, error) {
+	const op = "bannerservice.GetFeatureSchema"
+	schema, err := s.storage.GetFeatureSchema(ctx, featureID)
+	if err != nil {
+		if !errors.Is(err, models.SchemaNotFound) {
+			s.log.Errorf("%s Failed to get schema of feature %d: %v", op, featureID, err)
+		}
+		return models.FeatureSchema{}, err
+	}
+
+	return schema, nil
+}
+
+// SetFeatureSchema replaces the schema of the feature. A schema that does not
+// compile is rejected with models.InvalidSchema. Existing banners are not
+// checked, only writes after the change are.
+func (s *service) SetFeatureSchema(ctx context.Context, featureID int, schema json.RawMessage) (models.FeatureSchema, error) {
+	const op = "bannerservice.SetFeatureSchema"
+	if _, err := compileSchema(featureID, schema); err != nil {
+		return models.FeatureSchema{}, err
+	}
+
+	saved, err := s.storage.SaveFeatureSchema(ctx, featureID, schema)
+	if err != nil {
+		s.log.Errorf("%s Failed to save schema of feature %d: %v", op, featureID, err)
+		return models.FeatureSchema{}, err
+	}
+
+	return saved, nil
+}
+
+func (s *service) DeleteFeatureSchema(ctx context.Context, featureID int) error {
+	const op = "bannerservice.DeleteFeatureSchema"
+	if err := s.storage.DeleteFeatureSchema(ctx, featureID); err != nil {
+		if !errors.Is(err, models.SchemaNotFound) {
+			s.log.Errorf("%s Failed to delete schema of feature %d: %v", op, featureID, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// checkContent validates the banner content and its localized variants
+// against the schema of its feature, features without a schema accept any
+// content. Variants are only allowed in the supported locales.
+func (s *service) checkContent(ctx context.Context, banner models.Banner) error {
+	return s.contentChecker()(ctx, banner)
+}
+
+// contentChecker returns checkContent that compiles every feature schema only
+// once, for checking many banners in a row.
+func (s *service) contentChecker() func(ctx context.Context, banner models.Banner) error {
+	schemas := make(map[int]*jsonschema.Schema)
+	return func(ctx context.Context, banner models.Banner) error {
+		if err := s.checkLocales(banner); err != nil {
+			return err
+		}
+
+		schema, ok := schemas[banner.FeatureID]
+		if !ok {
+			featureSchema, err := s.storage.GetFeatureSchema(ctx, banner.FeatureID)
+			if err != nil && !errors.Is(err, models.SchemaNotFound) {
+				return err
+			}
+			if err == nil {
+				if schema, err = compileSchema(banner.FeatureID, featureSchema.Schema); err != nil {
+					return err
+				}
+			}
+			schemas[banner.FeatureID] = schema
+		}
+
+		if schema == nil {
+			return nil
+		}
+		return validateContent(schema, banner)
+	}
+}
+
+// checkLocales rejects variants in locales that are not supported or in the
+// default locale, whose content is the banner content itself.
+func (s *service) checkLocales(banner models.Banner) error {
+	res := &models.ContentValidationError{FeatureID: banner.FeatureID}
+	for _, locale := range sortedLocales(banner.LocalizedContent) {
+		if locale == s.cfg.defaultLocale || !slices.Contains(s.cfg.locales, locale) {
+			res.Errors = append(res.Errors, models.ContentError{Locale: locale, Message: fmt.Sprintf("locale %q is not supported", locale)})
+		}
+	}
+
+	if len(res.Errors) > 0 {
+		return res
+	}
+	return nil
+}
+
+// compileSchema compiles a schema taken from a request. Only the schema
+// itself is available to it, $refs to files or URLs fail instead of reading
+// the server disk or network.
+func compileSchema(featureID int, raw []byte) (*jsonschema.Schema, error) {
+	url := fmt.Sprintf("feature/%d/schema.json", featureID)
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("loading %s is not allowed", s)
+	}
+	if err := compiler.AddResource(url, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("%w: %s", models.InvalidSchema, err)
+	}
+
+	schema, err := compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", models.InvalidSchema, err)
+	}
+
+	return schema, nil
+}
+
+// validateContent reports every failing leaf of the validation of the content
+// and of each variant as a models.ContentError inside
+// *models.ContentValidationError.
+func validateContent(schema *jsonschema.Schema, banner models.Banner) error {
+	res := &models.ContentValidationError{FeatureID: banner.FeatureID}
+	if err := validateVariant(schema, "", banner.Content, &res.Errors); err != nil {
+		return err
+	}
+	for _, locale := range sortedLocales(banner.LocalizedContent) {
+		if err := validateVariant(schema, locale, banner.LocalizedContent[locale], &res.Errors); err != nil {
+			return err
+		}
+	}
+
+	if len(res.Errors) > 0 {
+		return res
+	}
+	return nil
+}
+
+func validateVariant(schema *jsonschema.Schema, locale string, variant map[string]any, errs *[]models.ContentError) error {
+	// The validator only understands values as encoding/json decodes them.
+	raw, err := json.Marshal(variant)
+	if err != nil {
+		return err
+	}
+	var content any
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return err
+	}
+
+	err = schema.Validate(content)
+	var invalid *jsonschema.ValidationError
+	if !errors.As(err, &invalid) {
+		return err
+	}
+
+	collectContentErrors(invalid, locale, errs)
+	return nil
+}
+
+func collectContentErrors(err *jsonschema.ValidationError, locale string, errs *[]models.ContentError) {
+	if len(err.Causes) == 0 {
+		path := err.InstanceLocation
+		if path == "" {
+			path = "/"
+		}
+		*errs = append(*errs, models.ContentError{Locale: locale, Path: path, Message: err.Message})
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectContentErrors(cause, locale, errs)
+	}
+}
+
+// sortedLocales keeps the reported errors in a stable order.
+func sortedLocales(localized map[string]map[string]any) []string {
+	locales := make([]string, 0, len(localized))
+	for locale := range localized {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
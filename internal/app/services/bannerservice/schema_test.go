@@ -0,0 +1,168 @@
+package bannerservice
+
+import (
+	"context"
+	"errors"
+	"project/internal/app/models"
+	"slices"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["title", "url"],
+	"properties": {
+		"title": {"type": "string"},
+		"url": {"type": "string"},
+		"priority": {"type": "integer"}
+	}
+}`
+
+func TestValidateContent(t *testing.T) {
+	schema, err := compileSchema(1, []byte(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := models.Banner{FeatureID: 1, Content: map[string]any{"title": "t", "url": "u", "priority": 2}}
+	if err := validateContent(schema, valid); err != nil {
+		t.Errorf("expected content to be valid, got %v", err)
+	}
+
+	invalid := models.Banner{FeatureID: 1, Content: map[string]any{"title": "t", "priority": "high"}}
+	err = validateContent(schema, invalid)
+	var contentErr *models.ContentValidationError
+	if !errors.As(err, &contentErr) {
+		t.Fatalf("expected ContentValidationError, got %v", err)
+	}
+
+	paths := make([]string, len(contentErr.Errors))
+	for i, e := range contentErr.Errors {
+		paths[i] = e.Path
+	}
+	slices.Sort(paths)
+	if want := []string{"/", "/priority"}; !slices.Equal(paths, want) {
+		t.Errorf("expected failing paths %v, got %v", want, paths)
+	}
+}
+
+func TestCompileSchema_Invalid(t *testing.T) {
+	if _, err := compileSchema(1, []byte(`{"type": 5}`)); !errors.Is(err, models.InvalidSchema) {
+		t.Errorf("expected InvalidSchema, got %v", err)
+	}
+}
+
+func TestCompileSchema_RemoteRef(t *testing.T) {
+	for _, ref := range []string{"file:///etc/passwd", "http://127.0.0.1/schema.json"} {
+		raw := []byte(`{"$ref": "` + ref + `"}`)
+		if _, err := compileSchema(1, raw); !errors.Is(err, models.InvalidSchema) {
+			t.Errorf("expected %s to be refused, got %v", ref, err)
+		}
+	}
+}
+
+// schemaStorage keeps banners by id and their revisions by version for the
+// write paths checked against feature schemas.
+type schemaStorage struct {
+	fakeStorage
+	schemas   map[int]models.FeatureSchema
+	byID      map[int]models.Banner
+	deleted   map[int]models.Banner
+	revisions map[int]map[int]models.Banner
+	created   int
+}
+
+func (f *schemaStorage) GetFeatureSchema(ctx context.Context, featureID int) (models.FeatureSchema, error) {
+	schema, ok := f.schemas[featureID]
+	if !ok {
+		return models.FeatureSchema{}, models.SchemaNotFound
+	}
+	return schema, nil
+}
+
+func (f *schemaStorage) CreateBanner(ctx context.Context, banner models.Banner, author string) (int, error) {
+	f.created++
+	return f.created, nil
+}
+
+func (f *schemaStorage) UpdateBanner(ctx context.Context, bannerID int, patch models.BannerPatch, ifMatch []int, check func(before models.Banner, after models.Banner) error, author string) (models.Banner, models.Banner, error) {
+	before := f.byID[bannerID]
+	after := before
+	if err := patch.Apply(&after); err != nil {
+		return models.Banner{}, models.Banner{}, err
+	}
+	if err := check(before, after); err != nil {
+		return models.Banner{}, models.Banner{}, err
+	}
+	f.byID[bannerID] = after
+	return before, after, nil
+}
+
+func (f *schemaStorage) RestoreBannerRevision(ctx context.Context, bannerID int, version int, check func(models.Banner) error, author string) (models.Banner, models.Banner, error) {
+	before := f.byID[bannerID]
+	after, ok := f.revisions[bannerID][version]
+	if !ok {
+		return models.Banner{}, models.Banner{}, models.RevisionNotFound
+	}
+	if err := check(after); err != nil {
+		return models.Banner{}, models.Banner{}, err
+	}
+	f.byID[bannerID] = after
+	return before, after, nil
+}
+
+func (f *schemaStorage) RestoreBanner(ctx context.Context, bannerID int, check func(models.Banner) error) (models.Banner, error) {
+	banner, ok := f.deleted[bannerID]
+	if !ok {
+		return models.Banner{}, models.BannerNotFound
+	}
+	if err := check(banner); err != nil {
+		return models.Banner{}, err
+	}
+	delete(f.deleted, bannerID)
+	f.byID[bannerID] = banner
+	return banner, nil
+}
+
+func TestService_ContentSchemaWrites(t *testing.T) {
+	s := newTestService()
+	valid := models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 1, Content: map[string]any{"title": "t", "url": "u"}}
+	old := models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 1, Content: map[string]any{"text": "before the schema"}}
+	storage := &schemaStorage{
+		schemas:   map[int]models.FeatureSchema{1: {FeatureID: 1, Schema: []byte(testSchema)}},
+		byID:      map[int]models.Banner{1: valid},
+		deleted:   map[int]models.Banner{2: {ID: 2, TagIDs: []int{2}, FeatureID: 1, Content: map[string]any{"title": 5}}},
+		revisions: map[int]map[int]models.Banner{1: {1: old}},
+	}
+	s.storage = storage
+	ctx := context.Background()
+
+	var invalid *models.ContentValidationError
+	if _, err := s.SaveBanner(ctx, old, "editor"); !errors.As(err, &invalid) {
+		t.Errorf("save: expected ContentValidationError, got %v", err)
+	}
+	if storage.created != 0 {
+		t.Error("save: expected invalid banner not to be stored")
+	}
+
+	patch := models.BannerPatch{Content: map[string]any{"url": nil}}
+	editor := models.Principal{Subject: "editor", Scopes: []string{models.ScopeBannersRead, models.ScopeBannersWrite}}
+	if _, err := s.UpdateBanner(ctx, 1, patch, nil, editor); !errors.As(err, &invalid) {
+		t.Errorf("update: expected ContentValidationError, got %v", err)
+	}
+
+	if err := s.ActivateBannerVersion(ctx, 1, 1, "editor"); !errors.As(err, &invalid) {
+		t.Errorf("activate: expected ContentValidationError, got %v", err)
+	}
+
+	if _, err := s.RestoreBanner(ctx, 2); !errors.As(err, &invalid) {
+		t.Errorf("restore: expected ContentValidationError, got %v", err)
+	}
+
+	if storage.byID[1].Content["url"] != "u" {
+		t.Errorf("expected the banner to keep its valid content, got %v", storage.byID[1].Content)
+	}
+	if _, ok := storage.deleted[2]; !ok {
+		t.Error("expected the invalid banner to stay deleted")
+	}
+}
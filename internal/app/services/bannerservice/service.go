@@ -3,151 +3,472 @@ package bannerservice
 import (
 	"context"
 	"errors"
+	"fmt"
+	"golang.org/x/sync/singleflight"
 	"project/internal/app/models"
 	"project/internal/logger"
+	"slices"
+	"time"
 )
 
 type bannerStorage interface {
 	GetBanner(ctx context.Context, tagID int, featureID int) (models.Banner, error)
-	GetBannersByTagID(ctx context.Context, tagID int, limit int, offset int) ([]models.Banner, error)
-	GetBannersByFeatureID(ctx context.Context, featureID int, limit int, offset int) ([]models.Banner, error)
-	GetBanners(ctx context.Context, limit int, offset int) ([]models.Banner, error)
-	UpdateBanner(ctx context.Context, banner models.Banner) (bool, error)
-	CreateBanner(ctx context.Context, banner models.Banner) (int, error)
-	DeleteBanner(ctx context.Context, bannerID int) (bool, error)
+	GetBanners(ctx context.Context, filter models.BannerFilter) ([]models.Banner, error)
+	GetBannersWithTotal(ctx context.Context, filter models.BannerFilter) ([]models.Banner, int, error)
+	GetBannerByID(ctx context.Context, bannerID int) (models.BannerDetails, error)
+	UpdateBanner(ctx context.Context, bannerID int, patch models.BannerPatch, ifMatch []int, check func(before models.Banner, after models.Banner) error, author string) (models.Banner, models.Banner, error)
+	CreateBanner(ctx context.Context, banner models.Banner, author string) (int, error)
+	CreateBanners(ctx context.Context, banners []models.Banner, author string) ([]int, error)
+	ExportBanners(ctx context.Context, fn func(models.Banner) error) error
+	DeleteBanner(ctx context.Context, bannerID int, ifMatch []int, check func(models.Banner) error) (models.Banner, error)
+	RestoreBanner(ctx context.Context, bannerID int, check func(models.Banner) error) (models.Banner, error)
+	PurgeDeletedBanners(ctx context.Context, deletedBefore time.Time) (int64, error)
+	CreateDeleteJob(ctx context.Context, featureID *int, tagID *int) (models.DeleteJob, error)
+	GetDeleteJob(ctx context.Context, jobID int) (models.DeleteJob, error)
+	ClaimDeleteJob(ctx context.Context) (models.DeleteJob, error)
+	UpdateDeleteJob(ctx context.Context, job models.DeleteJob) error
+	DeleteBannersBatch(ctx context.Context, filter models.BannerFilter, limit int) ([]models.Banner, error)
+	HasBanners(ctx context.Context, filter models.BannerFilter) (bool, error)
+	GetFeatureSchema(ctx context.Context, featureID int) (models.FeatureSchema, error)
+	SaveFeatureSchema(ctx context.Context, featureID int, schema []byte) (models.FeatureSchema, error)
+	DeleteFeatureSchema(ctx context.Context, featureID int) error
+	GetBannerRevisions(ctx context.Context, bannerID int) ([]models.BannerRevision, error)
+	RestoreBannerRevision(ctx context.Context, bannerID int, version int, check func(models.Banner) error, author string) (models.Banner, models.Banner, error)
 }
 
+// bannerCache keeps banners localized, one entry per locale of a (tag_id,
+// feature_id) pair. Invalidation drops the pair in every locale.
 type bannerCache interface {
-	SetBanner(ctx context.Context, tagID int, featureID int, banner models.Banner) error
-	GetBanner(ctx context.Context, tagID int, featureID int) (models.Banner, error)
+	Generation(ctx context.Context, tagID int, featureID int) (uint64, error)
+	SetBanner(ctx context.Context, tagID int, featureID int, locale string, banner models.Banner, generation uint64) error
+	GetBanner(ctx context.Context, tagID int, featureID int, locale string) (models.Banner, time.Duration, error)
+	InvalidateBanners(ctx context.Context, keys []models.BannerKey) error
+	TTL() time.Duration
 }
 
 type service struct {
 	log     logger.Logger
 	storage bannerStorage
 	cache   bannerCache
+	cfg     *serviceConfig
+	loads   singleflight.Group
+	// jobsWake nudges RunJobs when a job is enqueued.
+	jobsWake chan struct{}
 }
 
-func New(log logger.Logger, storage bannerStorage, cache bannerCache) *service {
+func New(log logger.Logger, storage bannerStorage, cache bannerCache) (*service, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Errorf("bannerservice.New Failed to load service config: %s", err)
+		return nil, err
+	}
+	// A window as long as the ttl would refresh on every hit.
+	if cfg.refreshWindow >= cache.TTL() {
+		err := fmt.Errorf("CACHE_REFRESH_WINDOW %s must be shorter than CACHE_TTL %s", cfg.refreshWindow, cache.TTL())
+		log.Errorf("bannerservice.New Failed to load service config: %s", err)
+		return nil, err
+	}
+
 	return &service{
-		log:     log,
-		storage: storage,
-		cache:   cache,
+		log:      log,
+		storage:  storage,
+		cache:    cache,
+		cfg:      cfg,
+		jobsWake: make(chan struct{}, 1),
+	}, nil
+}
+
+// GetUserBanner returns the banner for the tag and feature localized to the
+// first supported of the preferred locales, see models.NegotiateLocale.
+// Inactive banners and banners outside of their schedule window are only
+// visible to admins; everyone else gets models.BannerNotFound.
+func (s *service) GetUserBanner(ctx context.Context, tagID int, featureID int, locales []string, useLastRevision bool, admin bool) (models.Banner, error) {
+	locale := models.NegotiateLocale(locales, s.cfg.locales, s.cfg.defaultLocale)
+	banner, err := s.getUserBanner(ctx, tagID, featureID, locale, useLastRevision)
+	if err != nil {
+		return models.Banner{}, err
 	}
+
+	live := banner.IsActive && banner.ScheduleStatus(time.Now()) == models.ScheduleLive
+	if !live && !admin {
+		return models.Banner{}, models.BannerNotFound
+	}
+
+	return banner, nil
 }
 
-func (s *service) GetUserBanner(ctx context.Context, tagID int, featureID int, useLastRevision bool) (models.Banner, error) {
+func (s *service) getUserBanner(ctx context.Context, tagID int, featureID int, locale string, useLastRevision bool) (models.Banner, error) {
 	const op = "bannerservice.GetUserBanner"
 	if !useLastRevision {
-		cachedBanner, err := s.cache.GetBanner(ctx, tagID, featureID)
+		cachedBanner, ttl, err := s.cache.GetBanner(ctx, tagID, featureID, locale)
 		if err == nil {
+			if ttl < s.cfg.refreshWindow {
+				s.refreshBanner(tagID, featureID, locale)
+			}
 			return cachedBanner, nil
 		}
 		if !errors.Is(err, models.BannerNotFound) {
 			s.log.Errorf("%s Failed to get Banner from cache: %s", op, err)
 			return models.Banner{}, err
 		}
+
+		res, err, _ := s.loads.Do(loadKey(tagID, featureID, locale), func() (any, error) {
+			return s.loadBanner(tagID, featureID, locale)
+		})
+		if err != nil {
+			if !errors.Is(err, models.BannerNotFound) {
+				s.log.Errorf("%s Failed to load Banner: %s", op, err)
+			}
+			return models.Banner{}, err
+		}
+
+		return res.(models.Banner), nil
 	}
 
+	generation, generationErr := s.cache.Generation(ctx, tagID, featureID)
 	storageBanner, err := s.storage.GetBanner(ctx, tagID, featureID)
 	if err != nil {
 		s.log.Errorf("%s Failed to get Banner from storage: %s", op, err)
 		return models.Banner{}, err
 	}
+	storageBanner = storageBanner.Localize(locale, s.cfg.defaultLocale)
 
-	err = s.cache.SetBanner(ctx, tagID, featureID, storageBanner)
-	if err != nil {
-		s.log.Errorf("%s Failed to set Banner in cache: %s", op, err)
-	}
+	s.cacheBanner(ctx, op, generation, generationErr, tagID, featureID, locale, storageBanner)
 
 	return storageBanner, nil
 }
 
-func (s *service) GetBanners(ctx context.Context, tagID int, featureID int, limit int, offset int) ([]models.Banner, error) {
+func (s *service) GetBanners(ctx context.Context, filter models.BannerFilter) ([]models.Banner, error) {
 	const op = "bannerservice.GetBanners"
+	banners, err := s.storage.GetBanners(ctx, filter)
+	if err != nil {
+		s.log.Errorf("%s Failed to get banners for limit %d, offset %d: %v", op, filter.Limit, filter.Offset, err)
+		return nil, err
+	}
+
+	return banners, nil
+}
+
+func (s *service) GetBannersWithTotal(ctx context.Context, filter models.BannerFilter) ([]models.Banner, int, error) {
+	const op = "bannerservice.GetBannersWithTotal"
+	banners, total, err := s.storage.GetBannersWithTotal(ctx, filter)
+	if err != nil {
+		s.log.Errorf("%s Failed to get banners for limit %d, offset %d: %v", op, filter.Limit, filter.Offset, err)
+		return nil, 0, err
+	}
 
-	if tagID != -1 && featureID != -1 {
-		return s.getBannerByTagAndFeatureID(ctx, op, tagID, featureID)
+	return banners, total, nil
+}
+
+// GetBannersPage returns a page of banners around filter.Cursor (the first page
+// if it is nil) with cursors to the neighbouring pages.
+func (s *service) GetBannersPage(ctx context.Context, filter models.BannerFilter) (models.BannerPage, error) {
+	const op = "bannerservice.GetBannersPage"
+
+	limit := filter.Limit
+	filter.Limit = limit + 1
+	banners, err := s.storage.GetBanners(ctx, filter)
+	if err != nil {
+		s.log.Errorf("%s Failed to get banners for limit %d: %v", op, limit, err)
+		return models.BannerPage{}, err
+	}
+
+	hasMore := len(banners) > limit
+	if hasMore {
+		banners = banners[:limit]
+	}
+
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if backward {
+		slices.Reverse(banners)
 	}
 
-	if tagID != -1 {
-		return s.getBannersByTagID(ctx, op, tagID, limit, offset)
+	page := models.BannerPage{Items: banners}
+	if len(banners) == 0 {
+		return page, nil
 	}
 
-	if featureID != -1 {
-		return s.getBannersByFeatureID(ctx, op, featureID, limit, offset)
+	first, last := banners[0], banners[len(banners)-1]
+	if (backward && hasMore) || (!backward && filter.Cursor != nil) {
+		page.Prev = &models.BannerCursor{CreatedAt: first.CreatedAt, ID: first.ID, Backward: true}
+	}
+	if (!backward && hasMore) || backward {
+		page.Next = &models.BannerCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
+
+// GetBanner returns the admin view of the banner, models.BannerNotFound if
+// there is no banner with the id.
+func (s *service) GetBanner(ctx context.Context, id int) (models.BannerDetails, error) {
+	const op = "bannerservice.GetBanner"
+	banner, err := s.storage.GetBannerByID(ctx, id)
+	if err != nil {
+		if !errors.Is(err, models.BannerNotFound) {
+			s.log.Errorf("%s Failed to get banner %d: %v", op, id, err)
+		}
+		return models.BannerDetails{}, err
 	}
 
-	return s.getBanners(ctx, op, limit, offset)
+	return banner, nil
 }
 
-func (s *service) UpdateBanner(ctx context.Context, banner models.Banner) (ok bool, err error) {
+// UpdateBanner applies the patch for the caller and returns the updated
+// banner. A non-nil ifMatch lists the versions the banner is allowed to be
+// at. The caller needs banners:write for the feature the banner is in and
+// the one it moves to, checked under the row lock, see
+// models.Principal.AuthorizeBanner. The patched content must match the
+// feature schema, see checkContent.
+func (s *service) UpdateBanner(ctx context.Context, id int, patch models.BannerPatch, ifMatch []int, principal models.Principal) (models.Banner, error) {
 	const op = "bannerservice.UpdateBanner"
-	ok, err = s.storage.UpdateBanner(ctx, banner)
+	check := func(before models.Banner, after models.Banner) error {
+		if err := principal.AuthorizeBanner(models.ScopeBannersWrite, before, after.FeatureID); err != nil {
+			return err
+		}
+		return s.checkContent(ctx, after)
+	}
+
+	before, after, err := s.storage.UpdateBanner(ctx, id, patch, ifMatch, check, principal.Subject)
 	if err != nil {
-		s.log.Errorf("%s Failed to update banner %d: %v", op, banner.ID, err)
-		return false, err
+		var invalid *models.ContentValidationError
+		var forbidden *models.FeatureScopeError
+		if !errors.Is(err, models.BannerNotFound) && !errors.Is(err, models.VersionMismatch) && !errors.As(err, &invalid) && !errors.As(err, &forbidden) {
+			s.log.Errorf("%s Failed to update banner %d: %v", op, id, err)
+		}
+		return models.Banner{}, err
 	}
 
-	return ok, nil
+	s.invalidate(ctx, op, before, after)
+	return after, nil
 }
 
-func (s *service) SaveBanner(ctx context.Context, banner models.Banner) (int, error) {
+func (s *service) SaveBanner(ctx context.Context, banner models.Banner, author string) (int, error) {
 	const op = "bannerservice.SaveBanner"
-	id, err := s.storage.CreateBanner(ctx, banner)
+	if err := s.checkContent(ctx, banner); err != nil {
+		var invalid *models.ContentValidationError
+		if !errors.As(err, &invalid) {
+			s.log.Errorf("%s Failed to check banner content: %v", op, err)
+		}
+		return 0, err
+	}
+
+	id, err := s.storage.CreateBanner(ctx, banner, author)
 	if err != nil {
 		s.log.Errorf("%s Failed to create banner %d: %v", op, banner.ID, err)
 		return 0, err
 	}
 
+	s.invalidate(ctx, op, banner)
 	return id, nil
 }
 
-func (s *service) DeleteBanner(ctx context.Context, id int) (ok bool, err error) {
+// SaveBanners creates all banners or none of them, see models.BulkImportError.
+func (s *service) SaveBanners(ctx context.Context, banners []models.Banner, author string) ([]int, error) {
+	const op = "bannerservice.SaveBanners"
+	var rejected []*models.BulkItemError
+	checkContent := s.contentChecker()
+	for i, banner := range banners {
+		err := checkContent(ctx, banner)
+		var invalid *models.ContentValidationError
+		if errors.As(err, &invalid) {
+			rejected = append(rejected, &models.BulkItemError{Index: i, Err: err})
+			continue
+		}
+		if err != nil {
+			s.log.Errorf("%s Failed to check content of banner %d: %v", op, i, err)
+			return nil, err
+		}
+	}
+	if len(rejected) > 0 {
+		return nil, &models.BulkImportError{Items: rejected}
+	}
+
+	ids, err := s.storage.CreateBanners(ctx, banners, author)
+	if err != nil {
+		var rejected *models.BulkImportError
+		if !errors.As(err, &rejected) {
+			s.log.Errorf("%s Failed to create %d banners: %v", op, len(banners), err)
+		}
+		return nil, err
+	}
+
+	s.invalidate(ctx, op, banners...)
+	return ids, nil
+}
+
+func (s *service) ExportBanners(ctx context.Context, fn func(models.Banner) error) error {
+	const op = "bannerservice.ExportBanners"
+	if err := s.storage.ExportBanners(ctx, fn); err != nil {
+		s.log.Errorf("%s Failed to export banners: %v", op, err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteBanner deletes the banner if the caller holds banners:delete for its
+// feature, checked under the row lock.
+func (s *service) DeleteBanner(ctx context.Context, id int, ifMatch []int, principal models.Principal) error {
 	const op = "bannerservice.DeleteBanner"
-	ok, err = s.storage.DeleteBanner(ctx, id)
+	check := func(banner models.Banner) error {
+		return principal.AuthorizeBanner(models.ScopeBannersDelete, banner)
+	}
+
+	deleted, err := s.storage.DeleteBanner(ctx, id, ifMatch, check)
 	if err != nil {
-		s.log.Errorf("%s Failed to delete banner %d: %v", op, id, err)
-		return false, err
+		var forbidden *models.FeatureScopeError
+		if !errors.Is(err, models.BannerNotFound) && !errors.Is(err, models.VersionMismatch) && !errors.As(err, &forbidden) {
+			s.log.Errorf("%s Failed to delete banner %d: %v", op, id, err)
+		}
+		return err
 	}
 
-	return ok, nil
+	s.invalidate(ctx, op, deleted)
+	return nil
 }
 
-func (s *service) getBannerByTagAndFeatureID(ctx context.Context, op string, tagID, featureID int) ([]models.Banner, error) {
-	banner, err := s.storage.GetBanner(ctx, tagID, featureID)
+// RestoreBanner undoes DeleteBanner, models.BannerNotFound means there is no
+// deleted banner with the id, it may have been purged already. The content
+// must still match the feature schema, see checkContent.
+func (s *service) RestoreBanner(ctx context.Context, id int) (models.Banner, error) {
+	const op = "bannerservice.RestoreBanner"
+	check := func(banner models.Banner) error {
+		return s.checkContent(ctx, banner)
+	}
+
+	restored, err := s.storage.RestoreBanner(ctx, id, check)
 	if err != nil {
-		s.log.Errorf("%s: Failed to get banner for tag %d and feature %d: %v", op, tagID, featureID, err)
-		return nil, err
+		var conflict *models.BannerConflictError
+		var invalid *models.ContentValidationError
+		if !errors.Is(err, models.BannerNotFound) && !errors.As(err, &conflict) && !errors.As(err, &invalid) {
+			s.log.Errorf("%s Failed to restore banner %d: %v", op, id, err)
+		}
+		return models.Banner{}, err
 	}
 
-	return []models.Banner{banner}, nil
+	s.invalidate(ctx, op, restored)
+	return restored, nil
 }
 
-func (s *service) getBannersByTagID(ctx context.Context, op string, tagID, limit, offset int) ([]models.Banner, error) {
-	banners, err := s.storage.GetBannersByTagID(ctx, tagID, limit, offset)
+// RunPurger hard-deletes banners that were deleted longer than the retention
+// ago, once per purge interval, until ctx is done.
+func (s *service) RunPurger(ctx context.Context) {
+	const op = "bannerservice.RunPurger"
+	ticker := time.NewTicker(s.cfg.purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.storage.PurgeDeletedBanners(ctx, time.Now().Add(-s.cfg.retention))
+		if err != nil && ctx.Err() == nil {
+			s.log.Errorf("%s Failed to purge deleted banners: %v", op, err)
+		}
+		if purged > 0 {
+			s.log.Infof("%s Purged %d deleted banners", op, purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *service) GetBannerVersions(ctx context.Context, id int) ([]models.BannerRevision, error) {
+	const op = "bannerservice.GetBannerVersions"
+	revisions, err := s.storage.GetBannerRevisions(ctx, id)
 	if err != nil {
-		s.log.Errorf("%s: Failed to get banners for tag %d: %v", op, tagID, err)
+		s.log.Errorf("%s Failed to get revisions of banner %d: %v", op, id, err)
 		return nil, err
 	}
-	return banners, nil
+
+	return revisions, nil
 }
 
-func (s *service) getBannersByFeatureID(ctx context.Context, op string, featureID, limit, offset int) ([]models.Banner, error) {
-	banners, err := s.storage.GetBannersByFeatureID(ctx, featureID, limit, offset)
+// ActivateBannerVersion rolls the banner back to the version. The old content
+// must match the current feature schema, see checkContent.
+func (s *service) ActivateBannerVersion(ctx context.Context, id int, version int, author string) error {
+	const op = "bannerservice.ActivateBannerVersion"
+	check := func(banner models.Banner) error {
+		return s.checkContent(ctx, banner)
+	}
+
+	before, after, err := s.storage.RestoreBannerRevision(ctx, id, version, check, author)
 	if err != nil {
-		s.log.Errorf("%s: Failed to get banners for feature %d: %v", op, featureID, err)
-		return nil, err
+		var invalid *models.ContentValidationError
+		if !errors.As(err, &invalid) {
+			s.log.Errorf("%s Failed to activate version %d of banner %d: %v", op, version, id, err)
+		}
+		return err
 	}
-	return banners, nil
+
+	s.invalidate(ctx, op, before, after)
+	return nil
 }
 
-func (s *service) getBanners(ctx context.Context, op string, limit, offset int) ([]models.Banner, error) {
-	banners, err := s.storage.GetBanners(ctx, limit, offset)
+// invalidate drops every (tag_id, feature_id) pair covered by the given banner
+// states from the cache. A failure is logged rather than returned because the
+// write itself has already been committed.
+func (s *service) invalidate(ctx context.Context, op string, banners ...models.Banner) {
+	var keys []models.BannerKey
+	for _, banner := range banners {
+		keys = append(keys, banner.Keys()...)
+	}
+
+	if err := s.cache.InvalidateBanners(ctx, keys); err != nil {
+		s.log.Errorf("%s Failed to invalidate cache: %v", op, err)
+	}
+}
+
+// cacheBanner puts a banner read from storage in the cache. generation is the
+// cache generation of the pair read before the storage read, if the pair has
+// been invalidated since, on this or any other instance, the banner may predate
+// the write behind it and the cache refuses it.
+func (s *service) cacheBanner(ctx context.Context, op string, generation uint64, generationErr error, tagID int, featureID int, locale string, banner models.Banner) {
+	if generationErr != nil {
+		s.log.Errorf("%s Failed to get cache generation: %s", op, generationErr)
+		return
+	}
+
+	err := s.cache.SetBanner(ctx, tagID, featureID, locale, banner, generation)
+	if err != nil && !errors.Is(err, models.StaleBanner) {
+		s.log.Errorf("%s Failed to set Banner in cache: %s", op, err)
+	}
+}
+
+// refreshBanner reloads the cache entry in the background. Concurrent refreshes
+// of the same (tag_id, feature_id) pair and locale are collapsed into a single
+// storage read.
+func (s *service) refreshBanner(tagID int, featureID int, locale string) {
+	s.loads.DoChan(loadKey(tagID, featureID, locale), func() (any, error) {
+		return s.loadBanner(tagID, featureID, locale)
+	})
+}
+
+// loadBanner reads the banner from storage and puts it in the cache. It is not
+// bound to a request context, so a cancelled caller does not abort the load
+// for everyone waiting on it.
+func (s *service) loadBanner(tagID int, featureID int, locale string) (models.Banner, error) {
+	const op = "bannerservice.loadBanner"
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.refreshTimeout)
+	defer cancel()
+
+	generation, generationErr := s.cache.Generation(ctx, tagID, featureID)
+	banner, err := s.storage.GetBanner(ctx, tagID, featureID)
 	if err != nil {
-		s.log.Errorf("%s: Failed to get banners for limit %d, offset %d: %v", op, limit, offset, err)
-		return nil, err
+		if !errors.Is(err, models.BannerNotFound) {
+			s.log.Errorf("%s Failed to get Banner from storage: %s", op, err)
+		}
+		return models.Banner{}, err
 	}
+	banner = banner.Localize(locale, s.cfg.defaultLocale)
 
-	return banners, nil
+	s.cacheBanner(ctx, op, generation, generationErr, tagID, featureID, locale, banner)
+
+	return banner, nil
+}
+
+func loadKey(tagID int, featureID int, locale string) string {
+	return fmt.Sprintf("%d:%d:%s", tagID, featureID, locale)
 }
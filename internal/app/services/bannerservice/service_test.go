@@ -0,0 +1,478 @@
+package bannerservice
+
+import (
+	"context"
+	"errors"
+	"project/internal/app/models"
+	"project/internal/logger"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeStorage struct {
+	bannerStorage
+	banners map[models.BannerKey]models.Banner
+	list    []models.Banner
+
+	purgedBefore []time.Time
+	jobs         []models.DeleteJob
+}
+
+func (f *fakeStorage) DeleteBannersBatch(ctx context.Context, filter models.BannerFilter, limit int) ([]models.Banner, error) {
+	var deleted []models.Banner
+	for len(f.list) > 0 && len(deleted) < limit {
+		deleted = append(deleted, f.list[0])
+		f.list = f.list[1:]
+	}
+	return deleted, nil
+}
+
+func (f *fakeStorage) HasBanners(ctx context.Context, filter models.BannerFilter) (bool, error) {
+	return len(f.list) > 0, nil
+}
+
+func (f *fakeStorage) UpdateDeleteJob(ctx context.Context, job models.DeleteJob) error {
+	f.jobs = append(f.jobs, job)
+	return nil
+}
+
+func (f *fakeStorage) GetBanner(ctx context.Context, tagID int, featureID int) (models.Banner, error) {
+	banner, ok := f.banners[models.BannerKey{TagID: tagID, FeatureID: featureID}]
+	if !ok {
+		return models.Banner{}, models.BannerNotFound
+	}
+	return banner, nil
+}
+
+func (f *fakeStorage) PurgeDeletedBanners(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	f.purgedBefore = append(f.purgedBefore, deletedBefore)
+	return 0, nil
+}
+
+type fakeCache struct {
+	banners     map[models.BannerKey]map[string]models.Banner
+	generations map[models.BannerKey]uint64
+}
+
+func (f *fakeCache) Generation(ctx context.Context, tagID int, featureID int) (uint64, error) {
+	return f.generations[models.BannerKey{TagID: tagID, FeatureID: featureID}], nil
+}
+
+func (f *fakeCache) SetBanner(ctx context.Context, tagID int, featureID int, locale string, banner models.Banner, generation uint64) error {
+	key := models.BannerKey{TagID: tagID, FeatureID: featureID}
+	if f.generations[key] != generation {
+		return models.StaleBanner
+	}
+	if f.banners[key] == nil {
+		f.banners[key] = map[string]models.Banner{}
+	}
+	f.banners[key][locale] = banner
+	return nil
+}
+
+func (f *fakeCache) GetBanner(ctx context.Context, tagID int, featureID int, locale string) (models.Banner, time.Duration, error) {
+	banner, ok := f.banners[models.BannerKey{TagID: tagID, FeatureID: featureID}][locale]
+	if !ok {
+		return models.Banner{}, 0, models.BannerNotFound
+	}
+	return banner, time.Minute, nil
+}
+
+func (f *fakeCache) InvalidateBanners(ctx context.Context, keys []models.BannerKey) error {
+	for _, key := range keys {
+		delete(f.banners, key)
+		f.generations[key]++
+	}
+	return nil
+}
+
+func (f *fakeCache) TTL() time.Duration {
+	return 5 * time.Minute
+}
+
+func newTestService(banners ...models.Banner) *service {
+	storage := &fakeStorage{banners: map[models.BannerKey]models.Banner{}}
+	for _, banner := range banners {
+		for _, key := range banner.Keys() {
+			storage.banners[key] = banner
+		}
+	}
+
+	return &service{
+		log:     logger.New(),
+		storage: storage,
+		cache:   &fakeCache{banners: map[models.BannerKey]map[string]models.Banner{}, generations: map[models.BannerKey]uint64{}},
+		cfg: &serviceConfig{
+			refreshWindow:  0,
+			refreshTimeout: time.Second,
+			defaultLocale:  "ru",
+			locales:        []string{"ru", "en"},
+		},
+	}
+}
+
+func TestService_GetUserBanner_Inactive(t *testing.T) {
+	s := newTestService(models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 1, IsActive: false})
+	ctx := context.Background()
+
+	for _, useLastRevision := range []bool{true, false} {
+		if _, err := s.GetUserBanner(ctx, 1, 1, nil, useLastRevision, false); !errors.Is(err, models.BannerNotFound) {
+			t.Errorf("expected BannerNotFound for user, got %v", err)
+		}
+
+		banner, err := s.GetUserBanner(ctx, 1, 1, nil, useLastRevision, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if banner.ID != 1 {
+			t.Errorf("expected banner 1 for admin, got %d", banner.ID)
+		}
+	}
+}
+
+func TestService_GetUserBanner_Schedule(t *testing.T) {
+	now := time.Now()
+	from, until := now.Add(time.Hour), now.Add(2*time.Hour)
+	s := newTestService(models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 1, IsActive: true, ActiveFrom: &from, ActiveUntil: &until})
+	ctx := context.Background()
+
+	if _, err := s.GetUserBanner(ctx, 1, 1, nil, true, false); !errors.Is(err, models.BannerNotFound) {
+		t.Errorf("expected BannerNotFound for scheduled banner, got %v", err)
+	}
+
+	if _, err := s.GetUserBanner(ctx, 1, 1, nil, true, true); err != nil {
+		t.Errorf("expected admin to see scheduled banner, got %v", err)
+	}
+}
+
+func TestService_GetUserBanner_Locale(t *testing.T) {
+	s := newTestService(models.Banner{
+		ID: 1, TagIDs: []int{1}, FeatureID: 1, IsActive: true,
+		Content:          map[string]any{"title": "Привет"},
+		LocalizedContent: map[string]map[string]any{"en": {"title": "Hello"}},
+	})
+	ctx := context.Background()
+	cache := s.cache.(*fakeCache)
+
+	tests := []struct {
+		locales []string
+		locale  string
+		title   string
+	}{
+		{locales: []string{"en-GB", "ru"}, locale: "en", title: "Hello"},
+		{locales: []string{"de"}, locale: "ru", title: "Привет"},
+		{locales: nil, locale: "ru", title: "Привет"},
+	}
+	for _, tt := range tests {
+		banner, err := s.GetUserBanner(ctx, 1, 1, tt.locales, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if banner.Locale != tt.locale || banner.Content["title"] != tt.title {
+			t.Errorf("expected %s content for %v, got %s %v", tt.locale, tt.locales, banner.Locale, banner.Content)
+		}
+		if _, ok := cache.banners[models.BannerKey{TagID: 1, FeatureID: 1}][tt.locale]; !ok {
+			t.Errorf("expected banner to be cached for %s", tt.locale)
+		}
+	}
+}
+
+func (f *fakeStorage) GetBanners(ctx context.Context, filter models.BannerFilter) ([]models.Banner, error) {
+	var banners []models.Banner
+	for _, banner := range f.list {
+		if filter.Cursor == nil ||
+			(!filter.Cursor.Backward && banner.ID > filter.Cursor.ID) ||
+			(filter.Cursor.Backward && banner.ID < filter.Cursor.ID) {
+			banners = append(banners, banner)
+		}
+	}
+	if filter.Cursor != nil && filter.Cursor.Backward {
+		slices.Reverse(banners)
+	}
+	return banners[:min(filter.Limit, len(banners))], nil
+}
+
+func TestService_GetBannersPage(t *testing.T) {
+	s := newTestService()
+	storage := s.storage.(*fakeStorage)
+	for i := 1; i <= 5; i++ {
+		storage.list = append(storage.list, models.Banner{ID: i})
+	}
+	ctx := context.Background()
+
+	page, err := s.GetBannersPage(ctx, models.BannerFilter{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Prev != nil || page.Next == nil || page.Next.ID != 2 {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+
+	page, err = s.GetBannersPage(ctx, models.BannerFilter{Limit: 2, Cursor: page.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Items[0].ID != 3 || page.Prev == nil || page.Next == nil {
+		t.Fatalf("unexpected second page %+v", page)
+	}
+
+	page, err = s.GetBannersPage(ctx, models.BannerFilter{Limit: 2, Cursor: page.Prev})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != 1 || page.Items[1].ID != 2 || page.Prev != nil {
+		t.Fatalf("unexpected page going back %+v", page)
+	}
+}
+
+func TestService_RunPurger(t *testing.T) {
+	s := newTestService()
+	s.cfg.retention = time.Hour
+	s.cfg.purgeInterval = time.Hour
+	storage := s.storage.(*fakeStorage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.RunPurger(ctx)
+
+	if len(storage.purgedBefore) != 1 {
+		t.Fatalf("expected a single purge before the first tick, got %d", len(storage.purgedBefore))
+	}
+	if cutoff := time.Since(storage.purgedBefore[0]); cutoff < time.Hour || cutoff > time.Hour+time.Minute {
+		t.Errorf("expected the cutoff to be retention ago, got %s", cutoff)
+	}
+}
+
+func TestService_RunDeleteJob(t *testing.T) {
+	s := newTestService()
+	s.cfg.deleteBatch = 2
+	storage := s.storage.(*fakeStorage)
+	cache := s.cache.(*fakeCache)
+	for i := 1; i <= 5; i++ {
+		banner := models.Banner{ID: i, TagIDs: []int{i}, FeatureID: 1}
+		storage.list = append(storage.list, banner)
+		cache.banners[models.BannerKey{TagID: i, FeatureID: 1}] = map[string]models.Banner{"ru": banner}
+	}
+
+	s.runDeleteJob(context.Background(), models.DeleteJob{ID: 1, Total: 5, Status: models.JobRunning})
+
+	progress := make([]int, len(storage.jobs))
+	for i, job := range storage.jobs {
+		progress[i] = job.Processed
+	}
+	if want := []int{2, 4, 5, 5}; !slices.Equal(progress, want) {
+		t.Errorf("expected progress %v, got %v", want, progress)
+	}
+
+	if last := storage.jobs[len(storage.jobs)-1]; last.Status != models.JobDone {
+		t.Errorf("expected job to be done, got %s", last.Status)
+	}
+
+	if len(cache.banners) != 0 {
+		t.Errorf("expected deleted banners to be invalidated, %d left in cache", len(cache.banners))
+	}
+}
+
+func TestService_RunDeleteJob_GrowingTotal(t *testing.T) {
+	s := newTestService()
+	s.cfg.deleteBatch = 2
+	storage := s.storage.(*fakeStorage)
+	for i := 1; i <= 5; i++ {
+		storage.list = append(storage.list, models.Banner{ID: i, TagIDs: []int{i}, FeatureID: 1})
+	}
+
+	// Two banners were created after the job counted three.
+	s.runDeleteJob(context.Background(), models.DeleteJob{ID: 1, Total: 3, Status: models.JobRunning})
+
+	for _, job := range storage.jobs {
+		if job.Processed > job.Total {
+			t.Errorf("expected processed to stay within total, got %d of %d", job.Processed, job.Total)
+		}
+	}
+	if last := storage.jobs[len(storage.jobs)-1]; last.Total != 5 || last.Processed != 5 {
+		t.Errorf("expected 5 of 5 banners, got %d of %d", last.Processed, last.Total)
+	}
+}
+
+// lockedStorage skips every banner in the first locked batches, as if
+// concurrent writes held them.
+type lockedStorage struct {
+	*fakeStorage
+	locked int
+}
+
+func (l *lockedStorage) DeleteBannersBatch(ctx context.Context, filter models.BannerFilter, limit int) ([]models.Banner, error) {
+	if l.locked > 0 {
+		l.locked--
+		return nil, nil
+	}
+	return l.fakeStorage.DeleteBannersBatch(ctx, filter, limit)
+}
+
+func TestService_RunDeleteJob_LockedBanners(t *testing.T) {
+	s := newTestService()
+	s.cfg.deleteBatch = 2
+	s.cfg.deleteRetry = time.Millisecond
+	storage := &lockedStorage{fakeStorage: s.storage.(*fakeStorage), locked: 2}
+	s.storage = storage
+	for i := 1; i <= 3; i++ {
+		storage.list = append(storage.list, models.Banner{ID: i, TagIDs: []int{i}, FeatureID: 1})
+	}
+
+	s.runDeleteJob(context.Background(), models.DeleteJob{ID: 1, Total: 3, Status: models.JobRunning})
+
+	last := storage.jobs[len(storage.jobs)-1]
+	if last.Status != models.JobDone || last.Processed != 3 {
+		t.Errorf("expected the job to wait for locked banners and delete all 3, got %s with %d", last.Status, last.Processed)
+	}
+}
+
+func TestService_UpdateBanner_FeatureScope(t *testing.T) {
+	s := newTestService()
+	storage := &schemaStorage{byID: map[int]models.Banner{
+		1: {ID: 1, TagIDs: []int{1}, FeatureID: 1},
+		2: {ID: 2, TagIDs: []int{1}, FeatureID: 2},
+	}}
+	s.storage = storage
+	ctx := context.Background()
+	editor := models.Principal{Subject: "editor", Scopes: []string{
+		models.FeatureScope(models.ScopeBannersRead, 1),
+		models.FeatureScope(models.ScopeBannersWrite, 1),
+	}}
+
+	active := true
+	if _, err := s.UpdateBanner(ctx, 1, models.BannerPatch{IsActive: &active}, nil, editor); err != nil {
+		t.Errorf("expected update within feature 1, got %v", err)
+	}
+
+	moved := 2
+	var forbidden *models.FeatureScopeError
+	if _, err := s.UpdateBanner(ctx, 1, models.BannerPatch{FeatureID: &moved}, nil, editor); !errors.As(err, &forbidden) || forbidden.FeatureID != 2 {
+		t.Errorf("expected moving to feature 2 to be forbidden, got %v", err)
+	}
+	if storage.byID[1].FeatureID != 1 {
+		t.Error("expected the banner to stay in feature 1")
+	}
+
+	if _, err := s.UpdateBanner(ctx, 2, models.BannerPatch{IsActive: &active}, nil, editor); !errors.Is(err, models.BannerNotFound) {
+		t.Errorf("expected a banner of feature 2 to be hidden, got %v", err)
+	}
+}
+
+// blockingStorage holds GetBanner until release is closed, reporting on
+// started once the read has begun.
+type blockingStorage struct {
+	*fakeStorage
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingStorage) GetBanner(ctx context.Context, tagID int, featureID int) (models.Banner, error) {
+	banner, err := b.fakeStorage.GetBanner(ctx, tagID, featureID)
+	close(b.started)
+	<-b.release
+	return banner, err
+}
+
+func TestService_LoadBanner_InvalidatedDuringRead(t *testing.T) {
+	old := models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 1, IsActive: true, Content: map[string]any{"title": "old"}}
+	s := newTestService(old)
+	storage := &blockingStorage{fakeStorage: s.storage.(*fakeStorage), started: make(chan struct{}), release: make(chan struct{})}
+	s.storage = storage
+	cache := s.cache.(*fakeCache)
+
+	done := make(chan error)
+	go func() {
+		_, err := s.GetUserBanner(context.Background(), 1, 1, nil, false, false)
+		done <- err
+	}()
+	<-storage.started
+
+	updated := old
+	updated.Content = map[string]any{"title": "new"}
+	storage.banners[models.BannerKey{TagID: 1, FeatureID: 1}] = updated
+	// The write lands on another instance, only the shared cache sees it.
+	if err := cache.InvalidateBanners(context.Background(), updated.Keys()); err != nil {
+		t.Fatal(err)
+	}
+
+	close(storage.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if cached, ok := cache.banners[models.BannerKey{TagID: 1, FeatureID: 1}]["ru"]; ok {
+		t.Errorf("expected the banner read before the invalidation to be dropped, cached %v", cached.Content)
+	}
+}
+
+func TestNew_RefreshWindow(t *testing.T) {
+	cache := &fakeCache{}
+	for window, valid := range map[string]bool{"1m": true, "0s": false, "5m": false, "10m": false} {
+		t.Setenv("CACHE_REFRESH_WINDOW", window)
+		if _, err := New(logger.New(), &fakeStorage{}, cache); (err == nil) != valid {
+			t.Errorf("window %s against a 5m ttl: expected valid %v, got %v", window, valid, err)
+		}
+	}
+}
+
+// countingStorage counts GetBanner calls and holds them until release is
+// closed.
+type countingStorage struct {
+	*fakeStorage
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (c *countingStorage) GetBanner(ctx context.Context, tagID int, featureID int) (models.Banner, error) {
+	c.calls.Add(1)
+	<-c.release
+	return c.fakeStorage.GetBanner(ctx, tagID, featureID)
+}
+
+func TestService_GetUserBanner_RefreshAhead(t *testing.T) {
+	cached := models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 1, IsActive: true, Content: map[string]any{"title": "cached"}}
+	s := newTestService(models.Banner{ID: 1, TagIDs: []int{1}, FeatureID: 1, IsActive: true, Content: map[string]any{"title": "stored"}})
+	s.cfg.refreshWindow = 2 * time.Minute
+	storage := &countingStorage{fakeStorage: s.storage.(*fakeStorage), release: make(chan struct{})}
+	s.storage = storage
+	cache := s.cache.(*fakeCache)
+	cache.banners[models.BannerKey{TagID: 1, FeatureID: 1}] = map[string]models.Banner{"ru": cached}
+
+	// The fake cache reports a minute left, inside the refresh window.
+	var wg sync.WaitGroup
+	titles := make([]any, 5)
+	for i := range titles {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			banner, err := s.GetUserBanner(context.Background(), 1, 1, nil, false, false)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			titles[i] = banner.Content["title"]
+		}(i)
+	}
+	wg.Wait()
+
+	for _, title := range titles {
+		if title != "cached" {
+			t.Errorf("expected the cached banner while refreshing, got %v", title)
+		}
+	}
+
+	close(storage.release)
+	// Do joins the refresh still in flight, if any, so it has finished after.
+	s.loads.Do(loadKey(1, 1, "ru"), func() (any, error) { return nil, nil })
+
+	if calls := storage.calls.Load(); calls != 1 {
+		t.Errorf("expected a single refresh, got %d", calls)
+	}
+	if banner := cache.banners[models.BannerKey{TagID: 1, FeatureID: 1}]["ru"]; banner.Content["title"] != "stored" {
+		t.Errorf("expected the refresh to replace the cached banner, got %v", banner.Content)
+	}
+}